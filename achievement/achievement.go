@@ -0,0 +1,7 @@
+package achievement
+
+type Badge struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
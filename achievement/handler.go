@@ -0,0 +1,67 @@
+package achievement
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fdp7/beachvolleyapp-api/store"
+)
+
+func GetAchievements(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	badges := make([]Badge, len(store.AchievementRules))
+	for i, rule := range store.AchievementRules {
+		badges[i] = Badge{
+			ID:          rule.ID,
+			Name:        rule.Name,
+			Description: rule.Description,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"achievements": badges})
+}
+
+// rewrite the badges of every player from the match history; the route is restricted to admins
+func RecomputeAchievements(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	if err := store.DBSport.RecomputeAchievements(ctx, sport); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to recompute achievements",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
@@ -18,6 +18,9 @@ import (
 
 var jwtKey []byte
 
+// key of the authenticated user name in the request context
+const UserNameKey = "userName"
+
 type JWTClaim struct {
 	Name string `json:"name"`
 	jwt.StandardClaims
@@ -50,7 +53,7 @@ func GenerateJWT(name string) (string, error) {
 	return tokenString, nil
 }
 
-func ValidateToken(signedToken string) error {
+func ValidateToken(signedToken string) (*JWTClaim, error) {
 	sToken := strings.TrimPrefix(signedToken, "Bearer ")
 
 	token, err := jwt.ParseWithClaims(
@@ -61,19 +64,19 @@ func ValidateToken(signedToken string) error {
 		},
 	)
 	if err != nil {
-		return fmt.Errorf("failed to parse token: %w", err)
+		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	claims, ok := token.Claims.(*JWTClaim)
 	if !ok {
-		return fmt.Errorf("couldn't parse claims: %w", err)
+		return nil, fmt.Errorf("couldn't parse claims: %w", err)
 	}
 
 	if claims.ExpiresAt < time.Now().Local().Unix() {
-		return fmt.Errorf("token expired: %w", err)
+		return nil, fmt.Errorf("token expired: %w", err)
 	}
 
-	return nil
+	return claims, nil
 }
 
 func GenerateToken(ctx *gin.Context) {
@@ -147,13 +150,43 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
-		if err := ValidateToken(tokenString); err != nil {
+		claims, err := ValidateToken(tokenString)
+		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"message": "token validation failed",
 			})
 			return
 		}
 
+		ctx.Set(UserNameKey, claims.Name)
+
+		ctx.Next()
+	}
+}
+
+// Admin lets through only the users listed as admins; it must follow Auth
+func Admin() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !IsAdmin(ctx.GetString(UserNameKey)) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"message": "user is not an admin",
+			})
+			return
+		}
+
 		ctx.Next()
 	}
 }
+
+// IsAdmin tells whether the user is among the comma-separated ADMIN_USERS
+func IsAdmin(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, admin := range strings.Split(viper.GetString("ADMIN_USERS"), ",") {
+		if strings.TrimSpace(admin) == name {
+			return true
+		}
+	}
+	return false
+}
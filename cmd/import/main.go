@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"github.com/fdp7/beachvolleyapp-api/match"
+	"github.com/fdp7/beachvolleyapp-api/store"
+)
+
+// import past matches from a CSV or JSON Lines file, e.g.
+//
+//	go run ./cmd/import -sport beachvolley -file results.csv -dry-run
+func main() {
+	sportStr := flag.String("sport", "", "sport of the matches")
+	file := flag.String("file", "", "CSV or JSON Lines file to import")
+	format := flag.String("format", "", "csv or jsonl, by default from the file extension")
+	dryRun := flag.Bool("dry-run", false, "validate and report without recording anything")
+	createPlayers := flag.Bool("create-players", false, "add the players not registered yet as guests instead of reporting them")
+	flag.Parse()
+
+	sport := store.Sport(*sportStr)
+	if _, ok := store.EnabledSport[sport]; !ok {
+		log.Fatalf("sport %q is not enabled", *sportStr)
+	}
+	if *file == "" {
+		log.Fatal("file is required")
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*file), ".")
+	}
+
+	ctx := context.Background()
+
+	viper.SetConfigFile("app.env")
+	viper.AutomaticEnv()
+
+	if err := viper.ReadInConfig(); err != nil {
+		log.Printf("error while reading configuration file: %s\n", err.Error())
+	}
+
+	_, dbSport, err := store.NewMongoDBStore(ctx, viper.GetString("CONNECTIONSTRING_MONGODB"))
+	if err != nil {
+		log.Fatalf("failed to initialize DB: %s", err.Error())
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("failed to open file: %s", err.Error())
+	}
+	defer f.Close()
+
+	rows, rowErrors, err := match.ParseMatches(f, *format)
+	if err != nil {
+		log.Fatalf("failed to read matches: %s", err.Error())
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if len(rowErrors) > 0 {
+		_ = encoder.Encode(rowErrors)
+		log.Fatalf("%d invalid rows", len(rowErrors))
+	}
+
+	report, err := dbSport.ImportMatches(ctx, rows, *dryRun, *createPlayers, sport)
+	if err != nil {
+		log.Fatalf("failed to import matches: %s", err.Error())
+	}
+
+	_ = encoder.Encode(report)
+	if len(report.Errors) > 0 {
+		log.Fatalf("%d invalid rows", len(report.Errors))
+	}
+}
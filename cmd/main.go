@@ -3,13 +3,17 @@ package main
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 
+	"github.com/fdp7/beachvolleyapp-api/achievement"
 	"github.com/fdp7/beachvolleyapp-api/auth"
+	"github.com/fdp7/beachvolleyapp-api/event"
 	"github.com/fdp7/beachvolleyapp-api/match"
 	"github.com/fdp7/beachvolleyapp-api/player"
+	"github.com/fdp7/beachvolleyapp-api/session"
 	"github.com/fdp7/beachvolleyapp-api/store"
 	"github.com/fdp7/beachvolleyapp-api/user"
 )
@@ -33,6 +37,12 @@ func main() {
 		log.Fatalf("failed to initialize DB: %s", err.Error())
 	}
 
+	// take ranking snapshots periodically, to show rank movements
+	go takeRankingSnapshots(ctx, viper.GetDuration("RANKING_SNAPSHOT_INTERVAL"))
+
+	// record pending matches nobody confirmed or rejected in time
+	go confirmExpiredMatches(ctx, viper.GetDuration("PENDING_MATCH_CHECK_INTERVAL"))
+
 	router := gin.Default()
 
 	router.POST("/user/signup", user.RegisterUser)
@@ -43,18 +53,101 @@ func main() {
 	{
 		// MATCH
 		secured.GET("/:sport/matches", match.GetMatches)
+		secured.GET("/:sport/matches/upsets", match.GetUpsets)
+		secured.GET("/:sport/matches/pending", match.GetPendingMatches)
+		secured.POST("/:sport/matches/import", auth.Admin(), match.ImportMatches)
+		secured.GET("/:sport/matches/export", match.ExportMatches)
+		secured.GET("/:sport/matches/stats", match.GetStatDefinitions)
 
 		secured.POST("/:sport/match", match.AddMatch)
+		secured.PUT("/:sport/match/:id", match.EditMatch)
 		secured.DELETE("/:sport/match", match.DeleteMatch)
+		secured.POST("/:sport/match/:id/confirm", match.ConfirmMatch)
+		secured.POST("/:sport/match/:id/reject", match.RejectMatch)
 
 		// PLAYER
 		secured.GET("/:sport/players", player.GetPlayers)
 		secured.POST("/:sport/players/balanceTeams", player.GenerateBalancedTeams)
+		secured.GET("/:sport/players/compare", player.ComparePlayers)
+		secured.GET("/:sport/players/export", player.ExportPlayers)
 
 		secured.GET("/:sport/player/:name", player.GetPlayer)
 		secured.GET("/:sport/player/ranking", player.GetRanking)
+		secured.GET("/:sport/player/streaks", player.GetStreakRanking)
 		secured.GET("/:sport/player/:name/mates", player.GetMates)
+		secured.GET("/:sport/player/:name/mates/matrix", player.GetMatesMatrix)
+		secured.GET("/:sport/player/:name/value", player.GetPlayerValue)
+		secured.GET("/:sport/player/:name/scores", player.GetScoreStats)
+		secured.GET("/:sport/player/:name/upsets", player.GetGiantKiller)
+		secured.GET("/:sport/player/:name/locations", player.GetLocationStats)
+		secured.GET("/:sport/player/:name/vs/:other", player.GetHeadToHead)
+
+		secured.GET("/player/:name/profile", player.GetProfile)
+		secured.PUT("/:sport/player/:name/attributes", player.UpdatePlayerAttributes)
+
+		secured.GET("/:sport/guests/claims", auth.Admin(), player.GetGuestClaims)
+		secured.POST("/:sport/guest/:name/claim", player.ClaimGuest)
+		secured.POST("/:sport/guest/:name/claim/confirm", auth.Admin(), player.ConfirmGuestClaim)
+		secured.POST("/:sport/guest/:name/claim/reject", auth.Admin(), player.RejectGuestClaim)
+
+		// ACHIEVEMENT
+		secured.GET("/:sport/achievements", achievement.GetAchievements)
+		secured.POST("/:sport/achievements/recompute", auth.Admin(), achievement.RecomputeAchievements)
+
+		// SESSION
+		secured.POST("/:sport/session/schedule", session.GenerateSchedule)
+
+		// EVENT
+		secured.GET("/:sport/events", event.GetEvents)
+
+		secured.POST("/:sport/event", event.AddEvent)
+		secured.GET("/:sport/event/:id", event.GetEvent)
+		secured.PUT("/:sport/event/:id/rsvp", event.RSVPEvent)
+		secured.POST("/:sport/event/:id/balanceTeams", event.GenerateBalancedTeams)
+		secured.POST("/:sport/event/:id/result", event.RecordResult)
 	}
 
 	router.Run()
 }
+
+func takeRankingSnapshots(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if store.DBSport == nil {
+			continue
+		}
+
+		for sport := range store.EnabledSport {
+			if err := store.DBSport.TakeRankingSnapshot(ctx, sport); err != nil {
+				log.Printf("failed to take ranking snapshot for %s: %s\n", sport, err.Error())
+			}
+		}
+	}
+}
+
+func confirmExpiredMatches(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if store.DBSport == nil {
+			continue
+		}
+
+		for sport := range store.EnabledSport {
+			if err := store.DBSport.ConfirmExpiredMatches(ctx, sport); err != nil {
+				log.Printf("failed to confirm expired matches for %s: %s\n", sport, err.Error())
+			}
+		}
+	}
+}
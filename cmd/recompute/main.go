@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/spf13/viper"
+
+	"github.com/fdp7/beachvolleyapp-api/store"
+)
+
+// recompute from the match history the streaks and badges of every player of a sport, e.g. to backfill
+// the stats of players who did not play since they were introduced
+//
+//	go run ./cmd/recompute -sport beachvolley
+func main() {
+	sportStr := flag.String("sport", "", "sport to recompute")
+	flag.Parse()
+
+	sport := store.Sport(*sportStr)
+	if _, ok := store.EnabledSport[sport]; !ok {
+		log.Fatalf("sport %q is not enabled", *sportStr)
+	}
+
+	ctx := context.Background()
+
+	viper.SetConfigFile("app.env")
+	viper.AutomaticEnv()
+
+	if err := viper.ReadInConfig(); err != nil {
+		log.Printf("error while reading configuration file: %s\n", err.Error())
+	}
+
+	_, dbSport, err := store.NewMongoDBStore(ctx, viper.GetString("CONNECTIONSTRING_MONGODB"))
+	if err != nil {
+		log.Fatalf("failed to initialize DB: %s", err.Error())
+	}
+
+	if err := dbSport.RecomputeStreaks(ctx, sport); err != nil {
+		log.Fatalf("failed to recompute streaks: %s", err.Error())
+	}
+
+	if err := dbSport.RecomputeAchievements(ctx, sport); err != nil {
+		log.Fatalf("failed to recompute achievements: %s", err.Error())
+	}
+
+	log.Printf("recomputed streaks and achievements for %s\n", sport)
+}
@@ -0,0 +1,45 @@
+package event
+
+import "time"
+
+type Event struct {
+	Date     time.Time `json:"date"`
+	Location string    `json:"location"`
+	Capacity int       `json:"capacity"`
+}
+
+type RSVP struct {
+	Status string `json:"status"`
+}
+
+type BalanceRequest struct {
+	Rules      []AttributeRule `json:"rules"`
+	OddMode    string          `json:"odd_mode"`
+	Substitute string          `json:"substitute"`
+}
+
+type AttributeRule struct {
+	Attribute     string `json:"attribute"`
+	Value         string `json:"value,omitempty"`
+	MaxDifference int    `json:"max_difference,omitempty"`
+}
+
+// Result of an event; date and location are the ones of the event
+type Result struct {
+	TeamA  []string   `json:"team_a"`
+	TeamB  []string   `json:"team_b"`
+	ScoreA int        `json:"score_a"`
+	ScoreB int        `json:"score_b"`
+	Sets   []SetScore `json:"sets,omitempty"`
+
+	Notes           string             `json:"notes,omitempty"`
+	DurationMinutes int                `json:"duration_minutes,omitempty"`
+	GuestRatings    map[string]float64 `json:"guest_ratings,omitempty"`
+
+	StatLines map[string]map[string]int `json:"stat_lines,omitempty"`
+}
+
+type SetScore struct {
+	ScoreA int `json:"score_a"`
+	ScoreB int `json:"score_b"`
+}
@@ -0,0 +1,425 @@
+package event
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fdp7/beachvolleyapp-api/auth"
+	"github.com/fdp7/beachvolleyapp-api/store"
+)
+
+func AddEvent(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	event := &Event{}
+	if err := ctx.BindJSON(event); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid event data",
+		})
+		return
+	}
+
+	storeEvent := &store.Event{
+		Date:      event.Date,
+		Location:  event.Location,
+		Capacity:  event.Capacity,
+		Organizer: ctx.GetString(auth.UserNameKey),
+	}
+
+	id, err := store.DBSport.AddEvent(ctx, storeEvent, sport)
+	if errors.Is(err, store.ErrNotValidEvent) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to add event",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+func GetEvents(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	events, err := store.DBSport.GetEvents(ctx, sport)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to retrieve events",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+func GetEvent(ctx *gin.Context) {
+	id := ctx.Param("id")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	event, err := store.DBSport.GetEvent(ctx, id, sport)
+	if errors.Is(err, store.ErrNoEventFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no event found",
+		})
+
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to retrieve event",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"event":     event,
+		"attendees": event.Attendees(),
+	})
+}
+
+// set the response of the authenticated player to the event
+func RSVPEvent(ctx *gin.Context) {
+	id := ctx.Param("id")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	rsvp := &RSVP{}
+	if err := ctx.BindJSON(rsvp); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid rsvp data",
+		})
+		return
+	}
+
+	status := store.RSVPStatus(rsvp.Status)
+	if status != store.RSVPYes && status != store.RSVPNo && status != store.RSVPMaybe {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "status must be yes, no or maybe",
+		})
+		return
+	}
+
+	playerName := ctx.GetString(auth.UserNameKey)
+
+	err := store.DBSport.RSVPEvent(ctx, id, playerName, status, sport)
+	if errors.Is(err, store.ErrNoEventFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no event found",
+		})
+
+		return
+	}
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
+
+		return
+	}
+	if errors.Is(err, store.ErrEventFull) || errors.Is(err, store.ErrEventClosed) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to rsvp to event",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+// generate balanced teams with the players attending the event
+func GenerateBalancedTeams(ctx *gin.Context) {
+	id := ctx.Param("id")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	// balancing options are optional
+	request := &BalanceRequest{}
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.BindJSON(request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "invalid balance data",
+			})
+			return
+		}
+	}
+
+	oddMode := store.OddMode(request.OddMode)
+	if oddMode != "" && oddMode != store.OddBench && oddMode != store.OddShortHanded {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "odd_mode must be bench or short_handed",
+		})
+
+		return
+	}
+
+	storeRules := make([]store.AttributeRule, len(request.Rules))
+	for i, rule := range request.Rules {
+		storeRules[i] = store.AttributeRule{
+			Attribute:     rule.Attribute,
+			Value:         rule.Value,
+			MaxDifference: rule.MaxDifference,
+		}
+	}
+
+	opts := store.BalanceOptions{
+		Rules:      storeRules,
+		OddMode:    oddMode,
+		Substitute: request.Substitute,
+	}
+
+	balancedTeams, err := store.DBSport.BalanceEvent(ctx, id, opts, sport)
+	if errors.Is(err, store.ErrNoEventFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no event found",
+		})
+
+		return
+	}
+	if errors.Is(err, store.ErrNotEnoughPlayers) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "not enough attendees to generate teams",
+		})
+
+		return
+	}
+	if errors.Is(err, store.ErrSubstituteNotFound) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "substitute is not among the attendees",
+		})
+
+		return
+	}
+	if errors.Is(err, store.ErrRulesNotSatisfied) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"message": "attribute rules can't be satisfied by the attendees",
+		})
+
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to generate balanced teams",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"balancedTeam1":       balancedTeams.TeamA,
+		"balancedTeam2":       balancedTeams.TeamB,
+		"teamValueDifference": balancedTeams.TeamValueDifference,
+		"swaps":               balancedTeams.Swaps,
+		"bench":               balancedTeams.Bench,
+		"shortHanded":         balancedTeams.ShortHanded},
+	)
+}
+
+// submit the result of the event; as any submitted match, it is recorded once the opposing team confirms it
+func RecordResult(ctx *gin.Context) {
+	id := ctx.Param("id")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	result := &Result{}
+	if err := ctx.BindJSON(result); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid match data",
+		})
+		return
+	}
+
+	recordedBy := ctx.GetString(auth.UserNameKey)
+
+	matchID, err := store.DBSport.RecordEventResult(ctx, id, resultToStoreMatch(result), recordedBy, sport)
+	if errors.Is(err, store.ErrNoEventFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no event found",
+		})
+
+		return
+	}
+	if errors.Is(err, store.ErrEventClosed) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"message": "event result already recorded",
+		})
+
+		return
+	}
+	var validationErr *store.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid match data",
+			"errors":  validationErr.Fields,
+		})
+
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to record event result",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"id": matchID})
+}
+
+func resultToStoreMatch(r *Result) *store.Match {
+	// guest ratings can be given with or without the guest prefix
+	guestRatings := make(map[string]float64, len(r.GuestRatings))
+	for name, rating := range r.GuestRatings {
+		guestRatings[store.GuestName(name)] = rating
+	}
+
+	var sets []store.SetScore
+	for _, set := range r.Sets {
+		sets = append(sets, store.SetScore{
+			ScoreA: set.ScoreA,
+			ScoreB: set.ScoreB,
+		})
+	}
+
+	m := &store.Match{
+		TeamA:        r.TeamA,
+		TeamB:        r.TeamB,
+		ScoreA:       r.ScoreA,
+		ScoreB:       r.ScoreB,
+		Sets:         sets,
+		GuestRatings: guestRatings,
+
+		Notes:           r.Notes,
+		DurationMinutes: r.DurationMinutes,
+	}
+
+	if len(r.StatLines) > 0 {
+		m.StatLines = make(map[string]store.StatLine, len(r.StatLines))
+		for name, line := range r.StatLines {
+			m.StatLines[name] = line
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatJSON  = "json"
+)
+
+var ContentTypes = map[string]string{
+	FormatCSV:   "text/csv",
+	FormatJSONL: "application/x-ndjson",
+	FormatJSON:  "application/json",
+}
+
+var ErrNotValidFormat = errors.New("format must be csv, jsonl or json")
+
+// Writer writes records one at a time, so that a full history can be streamed
+type Writer struct {
+	format  string
+	w       io.Writer
+	csv     *csv.Writer
+	json    *json.Encoder
+	written int
+}
+
+// csvHeader is written as first line of CSV files
+func NewWriter(w io.Writer, format string, csvHeader []string) (*Writer, error) {
+	e := &Writer{format: format, w: w}
+
+	switch format {
+	case FormatCSV:
+		e.csv = csv.NewWriter(w)
+		if err := e.csv.Write(csvHeader); err != nil {
+			return nil, err
+		}
+	case FormatJSONL:
+		e.json = json.NewEncoder(w)
+	case FormatJSON:
+		e.json = json.NewEncoder(w)
+		if _, err := io.WriteString(w, "["); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrNotValidFormat
+	}
+
+	return e, nil
+}
+
+// write a record: csvRecord in CSV files, v as JSON otherwise
+func (e *Writer) Write(v interface{}, csvRecord []string) error {
+	defer func() { e.written++ }()
+
+	switch e.format {
+	case FormatCSV:
+		return e.csv.Write(csvRecord)
+	case FormatJSON:
+		if e.written > 0 {
+			if _, err := io.WriteString(e.w, ","); err != nil {
+				return err
+			}
+		}
+	}
+	return e.json.Encode(v)
+}
+
+func (e *Writer) Close() error {
+	switch e.format {
+	case FormatCSV:
+		e.csv.Flush()
+		return e.csv.Error()
+	case FormatJSON:
+		_, err := io.WriteString(e.w, "]")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package match
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fdp7/beachvolleyapp-api/store"
+)
+
+// CSV columns of exported matches; the file can be imported back
+var exportCSVColumns = []string{"id", "date", "team_a", "team_b", "score_a", "score_b", "sets",
+	"location", "notes", "duration_minutes", "recorded_by", "surprise", "upset", "rating_deltas"}
+
+func matchToCSVRecord(m store.Match) []string {
+	sets := make([]string, 0, len(m.Sets))
+	for _, set := range m.Sets {
+		sets = append(sets, fmt.Sprintf("%d-%d", set.ScoreA, set.ScoreB))
+	}
+
+	// rating deltas as name:delta, ordered by name
+	names := make([]string, 0, len(m.RatingDeltas))
+	for name := range m.RatingDeltas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	deltas := make([]string, 0, len(names))
+	for _, name := range names {
+		deltas = append(deltas, name+":"+strconv.FormatFloat(m.RatingDeltas[name], 'f', -1, 64))
+	}
+
+	return []string{
+		m.ID,
+		m.Date.Format(time.RFC3339),
+		strings.Join(m.TeamA, teamSeparator),
+		strings.Join(m.TeamB, teamSeparator),
+		strconv.Itoa(m.ScoreA),
+		strconv.Itoa(m.ScoreB),
+		strings.Join(sets, " "),
+		m.Location,
+		m.Notes,
+		strconv.Itoa(m.DurationMinutes),
+		m.RecordedBy,
+		strconv.FormatFloat(m.Surprise, 'f', -1, 64),
+		strconv.FormatBool(m.Upset),
+		strings.Join(deltas, teamSeparator),
+	}
+}
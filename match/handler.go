@@ -3,19 +3,31 @@ package match
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/fdp7/beachvolleyapp-api/auth"
+	"github.com/fdp7/beachvolleyapp-api/export"
 	"github.com/fdp7/beachvolleyapp-api/store"
 )
 
 const (
-	playerQueryParam    = "player"
-	matchDateQueryParam = "date"
+	playerQueryParam        = "player"
+	locationQueryParam      = "location"
+	matchDateQueryParam     = "date"
+	limitQueryParam         = "limit"
+	formatQueryParam        = "format"
+	dryRunQueryParam        = "dry_run"
+	createPlayersQueryParam = "create_players"
 )
 
+const defaultUpsetsLimit = 10
+
 func AddMatch(ctx *gin.Context) {
 	sportStr := ctx.Param("sport")
 
@@ -46,13 +58,319 @@ func AddMatch(ctx *gin.Context) {
 
 	storeMatch := matchToStoreMatch(match)
 
-	err := store.DBSport.AddMatch(ctx, storeMatch, sport)
+	// the match is recorded once the opposing team confirms it
+	submittedBy := ctx.GetString(auth.UserNameKey)
+	storeMatch.RecordedBy = submittedBy
+
+	id, err := store.DBSport.SubmitMatch(ctx, storeMatch, submittedBy, sport)
+	var validationErr *store.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid match data",
+			"errors":  validationErr.Fields,
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "failed to add match",
 		})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"id": id})
+}
+
+// import the matches of a CSV or JSON Lines file sent as request body; imported matches change ratings
+// right away, without confirmation, so the route is restricted to admins
+func ImportMatches(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	params := ctx.Request.URL.Query()
+
+	format := FormatCSV
+	if f := params.Get(formatQueryParam); f != "" {
+		format = f
+	}
+
+	dryRun := false
+	if d := params.Get(dryRunQueryParam); d != "" {
+		b, err := strconv.ParseBool(d)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "invalid dry run",
+			})
+
+			return
+		}
+		dryRun = b
+	}
+
+	createPlayers := false
+	if c := params.Get(createPlayersQueryParam); c != "" {
+		b, err := strconv.ParseBool(c)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "invalid create players",
+			})
+
+			return
+		}
+		createPlayers = b
+	}
+
+	rows, rowErrors, err := ParseMatches(ctx.Request.Body, format)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+	if len(rowErrors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid matches",
+			"errors":  rowErrors,
+		})
+
+		return
+	}
+
+	recordedBy := ctx.GetString(auth.UserNameKey)
+	for i := range rows {
+		rows[i].Match.RecordedBy = recordedBy
+	}
+
+	report, err := store.DBSport.ImportMatches(ctx, rows, dryRun, createPlayers, sport)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to import matches",
+		})
+
+		return
+	}
+	if len(report.Errors) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid matches",
+			"errors":  report.Errors,
+		})
+
+		return
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	ctx.JSON(status, gin.H{"report": report})
+}
+
+// stream the whole match history of a sport, in chronological order
+func ExportMatches(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	format := export.FormatJSON
+	if f := ctx.Request.URL.Query().Get(formatQueryParam); f != "" {
+		format = f
+	}
+	contentType, ok := export.ContentTypes[format]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": export.ErrNotValidFormat.Error(),
+		})
+
+		return
+	}
+
+	// the response starts with the first match, so that a failing query can still be reported
+	var writer *export.Writer
+	startResponse := func() error {
+		ctx.Header("Content-Type", contentType)
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-matches.%s", sport, format))
+		ctx.Status(http.StatusOK)
+
+		var err error
+		writer, err = export.NewWriter(ctx.Writer, format, exportCSVColumns)
+		return err
+	}
+
+	err := store.DBSport.ExportMatches(ctx, sport, func(m store.Match) error {
+		if writer == nil {
+			if err := startResponse(); err != nil {
+				return err
+			}
+		}
+		return writer.Write(m, matchToCSVRecord(m))
+	})
+	if err != nil && writer == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to export matches",
+		})
+
+		return
+	}
+	if err != nil {
+		log.Printf("failed to export matches: %s\n", err.Error())
+		return
+	}
+
+	if writer == nil {
+		if err := startResponse(); err != nil {
+			return
+		}
+	}
+	if err := writer.Close(); err != nil {
+		log.Printf("failed to export matches: %s\n", err.Error())
+	}
+}
+
+func GetPendingMatches(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	playerName := ctx.GetString(auth.UserNameKey)
+
+	pending, err := store.DBSport.GetPendingMatches(ctx, playerName, sport)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to retrieve pending matches",
+		})
+
+		return
 	}
-	ctx.JSON(http.StatusCreated, gin.H{})
+
+	ctx.JSON(http.StatusOK, gin.H{"pending": pending})
+}
+
+func ConfirmMatch(ctx *gin.Context) {
+	reviewPendingMatch(ctx, func(id string, playerName string, sport store.Sport) error {
+		return store.DBSport.ConfirmMatch(ctx, id, playerName, sport)
+	})
+}
+
+func RejectMatch(ctx *gin.Context) {
+	reviewPendingMatch(ctx, func(id string, playerName string, sport store.Sport) error {
+		return store.DBSport.RejectMatch(ctx, id, playerName, sport)
+	})
+}
+
+// confirm or reject a pending match on behalf of the authenticated player
+func reviewPendingMatch(ctx *gin.Context, review func(id string, playerName string, sport store.Sport) error) {
+	id := ctx.Param("id")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	playerName := ctx.GetString(auth.UserNameKey)
+
+	err := review(id, playerName, sport)
+	if errors.Is(err, store.ErrNoMatchFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no pending match found",
+		})
+
+		return
+	}
+	if errors.Is(err, store.ErrNotAllowedToConfirm) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "player can't review the match",
+		})
+
+		return
+	}
+	if errors.Is(err, store.ErrEventClosed) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"message": "another result was submitted for the event",
+		})
+
+		return
+	}
+	var validationErr *store.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid match data",
+			"errors":  validationErr.Fields,
+		})
+
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to review match",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{})
 }
 
 func GetMatches(ctx *gin.Context) {
@@ -77,8 +395,9 @@ func GetMatches(ctx *gin.Context) {
 	}
 
 	player := ctx.Request.URL.Query().Get(playerQueryParam)
+	location := ctx.Request.URL.Query().Get(locationQueryParam)
 
-	result, err := store.DBSport.GetMatches(ctx, player, sport)
+	result, err := store.DBSport.GetMatches(ctx, player, location, sport)
 	if errors.Is(err, store.ErrNoMatchFound) {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"message": "no match found",
@@ -106,6 +425,141 @@ func GetMatches(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"matches": matches})
 }
 
+func GetUpsets(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	limit := defaultUpsetsLimit
+	if l := ctx.Request.URL.Query().Get(limitQueryParam); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "invalid limit",
+			})
+
+			return
+		}
+		limit = n
+	}
+
+	upsets, err := store.DBSport.GetUpsets(ctx, limit, sport)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to retrieve upsets",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"upsets": upsets})
+}
+
+// individual stats that can be recorded for the players of a match of the sport
+func GetStatDefinitions(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	stats := store.SportMatchRules[sport].Stats
+	if stats == nil {
+		stats = []store.StatDefinition{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+func EditMatch(ctx *gin.Context) {
+	id := ctx.Param("id")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	match := &Match{}
+	if err := ctx.BindJSON(match); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid match data",
+		})
+		return
+	}
+
+	editedBy := ctx.GetString(auth.UserNameKey)
+
+	err := store.DBSport.EditMatch(ctx, id, matchToStoreMatch(match), editedBy, sport)
+	if errors.Is(err, store.ErrNoMatchFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no match found",
+		})
+
+		return
+	}
+	if errors.Is(err, store.ErrNotAllowedToEdit) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "player can't edit the match",
+		})
+
+		return
+	}
+	var validationErr *store.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid match data",
+			"errors":  validationErr.Fields,
+		})
+
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to edit match",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
 func DeleteMatch(ctx *gin.Context) {
 	sportStr := ctx.Param("sport")
 
@@ -135,7 +589,16 @@ func DeleteMatch(ctx *gin.Context) {
 		})
 	}
 
-	err = store.DBSport.DeleteMatch(ctx, FormattedMatchDate, sport)
+	deletedBy := ctx.GetString(auth.UserNameKey)
+
+	err = store.DBSport.DeleteMatch(ctx, FormattedMatchDate, deletedBy, sport)
+	if errors.Is(err, store.ErrNotAllowedToEdit) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "player can't delete the match",
+		})
+
+		return
+	}
 	if errors.Is(err, store.ErrNoMatchFound) {
 		ctx.JSON(http.StatusNoContent, gin.H{
 			"message": "no match found",
@@ -153,11 +616,45 @@ func DeleteMatch(ctx *gin.Context) {
 }
 
 func matchToStoreMatch(m *Match) *store.Match {
+	// guest ratings can be given with or without the guest prefix
+	guestRatings := make(map[string]float64, len(m.GuestRatings))
+	for name, rating := range m.GuestRatings {
+		guestRatings[store.GuestName(name)] = rating
+	}
+
+	var sets []store.SetScore
+	for _, set := range m.Sets {
+		sets = append(sets, store.SetScore{
+			ScoreA: set.ScoreA,
+			ScoreB: set.ScoreB,
+		})
+	}
+
 	return &store.Match{
-		TeamA:  m.TeamA,
-		TeamB:  m.TeamB,
-		ScoreA: m.ScoreA,
-		ScoreB: m.ScoreB,
-		Date:   m.Date,
+		TeamA:        m.TeamA,
+		TeamB:        m.TeamB,
+		ScoreA:       m.ScoreA,
+		ScoreB:       m.ScoreB,
+		Date:         m.Date,
+		Sets:         sets,
+		GuestRatings: guestRatings,
+
+		Location:        m.Location,
+		Notes:           m.Notes,
+		DurationMinutes: m.DurationMinutes,
+
+		StatLines: statLinesToStoreStatLines(m.StatLines),
+	}
+}
+
+func statLinesToStoreStatLines(statLines map[string]map[string]int) map[string]store.StatLine {
+	if len(statLines) == 0 {
+		return nil
+	}
+
+	storeStatLines := make(map[string]store.StatLine, len(statLines))
+	for name, line := range statLines {
+		storeStatLines[name] = line
 	}
+	return storeStatLines
 }
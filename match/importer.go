@@ -0,0 +1,223 @@
+package match
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fdp7/beachvolleyapp-api/store"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// in CSV files players of a team are separated by teamSeparator, e.g. "alice|bob",
+// and sets by spaces, e.g. "21-18 19-21 15-12"
+const teamSeparator = "|"
+
+// sets, location, notes and duration_minutes columns are optional
+var requiredCSVColumns = []string{"date", "team_a", "team_b", "score_a", "score_b"}
+
+var ErrNotValidFormat = errors.New("format must be csv or jsonl")
+
+// read the matches of a CSV (with header) or JSON Lines file; rows that can't be read are reported
+// with their line number
+func ParseMatches(r io.Reader, format string) ([]store.ImportRow, []store.ImportError, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSVMatches(r)
+	case FormatJSONL:
+		return parseJSONLMatches(r)
+	default:
+		return nil, nil, ErrNotValidFormat
+	}
+}
+
+func parseJSONLMatches(r io.Reader) ([]store.ImportRow, []store.ImportError, error) {
+	var rows []store.ImportRow
+	var rowErrors []store.ImportError
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		m := &Match{}
+		if err := json.Unmarshal(scanner.Bytes(), m); err != nil {
+			rowErrors = append(rowErrors, store.ImportError{
+				Line:   line,
+				Fields: []store.FieldError{{Field: "match", Message: err.Error()}},
+			})
+			continue
+		}
+
+		rows = append(rows, store.ImportRow{Line: line, Match: *matchToStoreMatch(m)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read matches: %w", err)
+	}
+
+	return rows, rowErrors, nil
+}
+
+func parseCSVMatches(r io.Reader) ([]store.ImportRow, []store.ImportError, error) {
+	var rows []store.ImportRow
+	var rowErrors []store.ImportError
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredCSVColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	// matches of the same day without time keep the order of the file, a minute apart
+	sameDay := make(map[time.Time]int)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			rowErrors = append(rowErrors, store.ImportError{
+				Line:   line,
+				Fields: []store.FieldError{{Field: "match", Message: err.Error()}},
+			})
+			continue
+		}
+
+		value := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		m, dateOnly, fields := csvRecordToMatch(value)
+		if len(fields) > 0 {
+			rowErrors = append(rowErrors, store.ImportError{Line: line, Fields: fields})
+			continue
+		}
+		if dateOnly {
+			day := m.Date
+			m.Date = day.Add(time.Duration(sameDay[day]) * time.Minute)
+			sameDay[day]++
+		}
+
+		rows = append(rows, store.ImportRow{Line: line, Match: *matchToStoreMatch(m)})
+	}
+
+	return rows, rowErrors, nil
+}
+
+func csvRecordToMatch(value func(column string) string) (*Match, bool, []store.FieldError) {
+	var fields []store.FieldError
+	m := &Match{
+		TeamA:    splitTeam(value("team_a")),
+		TeamB:    splitTeam(value("team_b")),
+		Location: value("location"),
+		Notes:    value("notes"),
+	}
+
+	if duration := value("duration_minutes"); duration != "" {
+		n, err := strconv.Atoi(duration)
+		if err != nil {
+			fields = append(fields, store.FieldError{Field: "duration_minutes", Message: "duration must be a number of minutes"})
+		}
+		m.DurationMinutes = n
+	}
+
+	date, dateOnly, err := parseDate(value("date"))
+	if err != nil {
+		fields = append(fields, store.FieldError{Field: "date", Message: "date must be RFC3339 or YYYY-MM-DD"})
+	}
+	m.Date = date
+
+	sets, err := parseSets(value("sets"))
+	if err != nil {
+		fields = append(fields, store.FieldError{Field: "sets", Message: "sets must be like 21-18 19-21 15-12"})
+	}
+	m.Sets = sets
+
+	// the score can be omitted when sets are given
+	for _, score := range []struct {
+		column string
+		value  *int
+	}{{"score_a", &m.ScoreA}, {"score_b", &m.ScoreB}} {
+		if value(score.column) == "" && len(sets) > 0 {
+			continue
+		}
+		n, err := strconv.Atoi(value(score.column))
+		if err != nil {
+			fields = append(fields, store.FieldError{Field: score.column, Message: "score must be a number"})
+			continue
+		}
+		*score.value = n
+	}
+
+	return m, dateOnly, fields
+}
+
+func splitTeam(team string) []string {
+	var players []string
+	for _, name := range strings.Split(team, teamSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			players = append(players, name)
+		}
+	}
+	return players
+}
+
+// parse a RFC3339 date or a day, telling which one it is
+func parseDate(date string) (time.Time, bool, error) {
+	if d, err := time.Parse(time.RFC3339, date); err == nil {
+		return d, false, nil
+	}
+	d, err := time.Parse("2006-01-02", date)
+	return d, true, err
+}
+
+func parseSets(sets string) ([]SetScore, error) {
+	var scores []SetScore
+	for _, set := range strings.Fields(sets) {
+		points := strings.Split(set, "-")
+		if len(points) != 2 {
+			return nil, fmt.Errorf("invalid set %s", set)
+		}
+		scoreA, errA := strconv.Atoi(points[0])
+		scoreB, errB := strconv.Atoi(points[1])
+		if errA != nil || errB != nil {
+			return nil, fmt.Errorf("invalid set %s", set)
+		}
+		scores = append(scores, SetScore{ScoreA: scoreA, ScoreB: scoreB})
+	}
+	return scores, nil
+}
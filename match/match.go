@@ -3,9 +3,50 @@ package match
 import "time"
 
 type Match struct {
-	TeamA  []string  `json:"team_a"`
-	TeamB  []string  `json:"team_b"`
-	ScoreA int       `json:"score_a"`
-	ScoreB int       `json:"score_b"`
-	Date   time.Time `json:"date"`
+	ID     string     `json:"id,omitempty"`
+	TeamA  []string   `json:"team_a"`
+	TeamB  []string   `json:"team_b"`
+	ScoreA int        `json:"score_a"`
+	ScoreB int        `json:"score_b"`
+	Date   time.Time  `json:"date"`
+	Sets   []SetScore `json:"sets,omitempty"`
+
+	Location        string `json:"location,omitempty"`
+	Notes           string `json:"notes,omitempty"`
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+	RecordedBy      string `json:"recorded_by,omitempty"`
+
+	StatLines map[string]map[string]int `json:"stat_lines,omitempty"`
+
+	RatingDeltas map[string]float64 `json:"rating_deltas,omitempty"`
+	Surprise     float64            `json:"surprise,omitempty"`
+	Upset        bool               `json:"upset,omitempty"`
+	History      []MatchEdit        `json:"history,omitempty"`
+	GuestRatings map[string]float64 `json:"guest_ratings,omitempty"`
+}
+
+type SetScore struct {
+	ScoreA int `json:"score_a"`
+	ScoreB int `json:"score_b"`
+}
+
+type MatchEdit struct {
+	EditedBy string      `json:"edited_by"`
+	EditedAt time.Time   `json:"edited_at"`
+	Previous MatchResult `json:"previous"`
+}
+
+type MatchResult struct {
+	TeamA  []string   `json:"team_a"`
+	TeamB  []string   `json:"team_b"`
+	ScoreA int        `json:"score_a"`
+	ScoreB int        `json:"score_b"`
+	Sets   []SetScore `json:"sets,omitempty"`
+	Date   time.Time  `json:"date"`
+
+	Location        string `json:"location,omitempty"`
+	Notes           string `json:"notes,omitempty"`
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+
+	StatLines map[string]map[string]int `json:"stat_lines,omitempty"`
 }
@@ -0,0 +1,29 @@
+package player
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/fdp7/beachvolleyapp-api/store"
+)
+
+// CSV columns of exported players; the elo trend is separated by "|"
+var exportCSVColumns = []string{"name", "match_count", "win_count", "draw_count", "last_elo", "longest_win_streak", "longest_loss_streak", "elo"}
+
+func playerToCSVRecord(p store.Player) []string {
+	elo := make([]string, 0, len(p.Elo))
+	for _, e := range p.Elo {
+		elo = append(elo, strconv.FormatFloat(e, 'f', -1, 64))
+	}
+
+	return []string{
+		p.Name,
+		strconv.Itoa(p.MatchCount),
+		strconv.Itoa(p.WinCount),
+		strconv.Itoa(p.DrawCount),
+		strconv.FormatFloat(p.LastElo, 'f', -1, 64),
+		strconv.Itoa(p.Streaks.LongestWin),
+		strconv.Itoa(p.Streaks.LongestLoss),
+		strings.Join(elo, "|"),
+	}
+}
@@ -3,23 +3,571 @@ package player
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/fdp7/beachvolleyapp-api/auth"
+	"github.com/fdp7/beachvolleyapp-api/export"
 	"github.com/fdp7/beachvolleyapp-api/store"
 )
 
+const (
+	byQueryParam         = "by"
+	minMatchesQueryParam = "min_matches"
+	fromQueryParam       = "from"
+	toQueryParam         = "to"
+	tiebreakQueryParam   = "tiebreak"
+	statQueryParam       = "stat"
+
+	namesQueryParam    = "names"
+	lastQueryParam     = "last"
+	sortQueryParam     = "sort"
+	orderQueryParam    = "order"
+	relationQueryParam = "relation"
+	minGamesQueryParam = "min_games"
+	formatQueryParam   = "format"
+
+	defaultLastMeetings = 5
+	defaultMinMatches   = 5
+	defaultGainPeriod   = 30 * 24 * time.Hour
+)
+
 func GetPlayers(ctx *gin.Context) {
 	sportStr := ctx.Param("sport")
 
 	sport := store.Sport(sportStr)
 	_, ok := store.EnabledSport[sport]
-
+
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	result, err := store.DBSport.GetPlayers(ctx, sport)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "failed to retrieve players",
+		})
+
+		return
+	}
+
+	players := &[]Player{}
+
+	if err := json.Unmarshal(result, players); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to unmarshal players",
+		})
+
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"players": players})
+}
+
+// stream every player and guest of a sport
+func ExportPlayers(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	format := export.FormatJSON
+	if f := ctx.Request.URL.Query().Get(formatQueryParam); f != "" {
+		format = f
+	}
+	contentType, ok := export.ContentTypes[format]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": export.ErrNotValidFormat.Error(),
+		})
+
+		return
+	}
+
+	// the response starts with the first player, so that a failing query can still be reported
+	var writer *export.Writer
+	startResponse := func() error {
+		ctx.Header("Content-Type", contentType)
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-players.%s", sport, format))
+		ctx.Status(http.StatusOK)
+
+		var err error
+		writer, err = export.NewWriter(ctx.Writer, format, exportCSVColumns)
+		return err
+	}
+
+	err := store.DBSport.ExportPlayers(ctx, sport, func(p store.Player) error {
+		if writer == nil {
+			if err := startResponse(); err != nil {
+				return err
+			}
+		}
+		return writer.Write(p, playerToCSVRecord(p))
+	})
+	if err != nil && writer == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to export players",
+		})
+
+		return
+	}
+	if err != nil {
+		log.Printf("failed to export players: %s\n", err.Error())
+		return
+	}
+
+	if writer == nil {
+		if err := startResponse(); err != nil {
+			return
+		}
+	}
+	if err := writer.Close(); err != nil {
+		log.Printf("failed to export players: %s\n", err.Error())
+	}
+}
+
+func GetPlayer(ctx *gin.Context) {
+	name := ctx.Param("name")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+		return
+	}
+
+	result, err := store.DBSport.GetPlayer(ctx, name, sport)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "can't find player",
+		})
+		return
+	}
+
+	player := &Player{}
+
+	if err := json.Unmarshal(result, player); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to unmarshal player",
+		})
+		return
+	}
+
+	response := gin.H{"player": player}
+
+	// score stats are an extra of the profile: without them the player is still returned
+	scoreStats, _, err := store.DBSport.GetScoreStats(ctx, name, sport)
+	if err != nil {
+		log.Printf("failed to get score stats of %s: %s\n", name, err.Error())
+	} else {
+		response["score_stats"] = scoreStats
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func ComparePlayers(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+		return
+	}
+
+	// compared names, without blanks and duplicates
+	var names []string
+	for _, name := range strings.Split(ctx.Request.URL.Query().Get(namesQueryParam), ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	comparison, err := store.DBSport.ComparePlayers(ctx, names, sport)
+	if errors.Is(err, store.ErrNotValidComparison) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "players to compare must be between 2 and 6",
+		})
+		return
+	}
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "can't compare players",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"comparison": comparison})
+}
+
+func GetProfile(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+		return
+	}
+
+	profile, err := store.DBSport.GetProfile(ctx, name)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "can't get player's profile",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+func GetScoreStats(ctx *gin.Context) {
+	name := ctx.Param("name")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+		return
+	}
+
+	scoreStats, pairings, err := store.DBSport.GetScoreStats(ctx, name, sport)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "can't get player's score stats",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"score_stats": scoreStats,
+		"pairings":    pairings,
+	})
+}
+
+func GetRanking(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	query, err := rankingQueryFromParams(ctx.Request.URL.Query(), sport)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	result, err := store.DBSport.GetRanking(ctx, query, sport)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "ranking is empty",
+		})
+
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to retrieve ranking",
+		})
+
+		return
+	}
+
+	ranking := &[]RankingEntry{}
+
+	if err := json.Unmarshal(result, ranking); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to unmarshal players",
+		})
+
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"ranking": ranking})
+}
+
+func GetStreakRanking(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	result, err := store.DBSport.GetStreakRanking(ctx, sport)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "nobody is on a win streak",
+		})
+
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to retrieve streak ranking",
+		})
+
+		return
+	}
+
+	players := &[]Player{}
+
+	if err := json.Unmarshal(result, players); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to unmarshal players",
+		})
+
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"ranking": players})
+}
+
+func GetMates(ctx *gin.Context) {
+	name := ctx.Param("name")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+		return
+	}
+
+	bF, wF, err := store.DBSport.GetMates(ctx, name, sport)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "can't get player's mates stats",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"bestfriend": bF,
+		"worstfoe":   wF,
+	})
+}
+
+func GetPlayerValue(ctx *gin.Context) {
+	name := ctx.Param("name")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+		return
+	}
+
+	value, err := store.DBSport.GetPlayerValue(ctx, name, sport)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "can't compute player value",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"value": value})
+}
+
+func GetLocationStats(ctx *gin.Context) {
+	name := ctx.Param("name")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+		return
+	}
+
+	locations, err := store.DBSport.GetLocationStats(ctx, name, sport)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to retrieve location stats",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"locations": locations})
+}
+
+func GetGiantKiller(ctx *gin.Context) {
+	name := ctx.Param("name")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
 	if !ok {
 		ctx.JSON(http.StatusNotAcceptable, gin.H{
 			"message": "sport is not enabled",
 		})
+
 		return
 	}
 
@@ -27,33 +575,119 @@ func GetPlayers(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "store is not initialized",
 		})
-
 		return
 	}
 
-	result, err := store.DBSport.GetPlayers(ctx, sport)
+	giantKiller, err := store.DBSport.GetGiantKiller(ctx, name, sport)
 	if errors.Is(err, store.ErrNoPlayerFound) {
 		ctx.JSON(http.StatusNotFound, gin.H{
-			"message": "failed to retrieve players",
+			"message": "no player found",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to retrieve upsets",
 		})
-
 		return
 	}
 
-	players := &[]Player{}
+	ctx.JSON(http.StatusOK, gin.H{"giant_killer": giantKiller})
+}
 
-	if err := json.Unmarshal(result, players); err != nil {
+func GetMatesMatrix(ctx *gin.Context) {
+	name := ctx.Param("name")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"message": "failed to unmarshal players",
+			"message": "store is not initialized",
 		})
+		return
+	}
+
+	query := store.MatesQuery{
+		Relation: store.RelationAll,
+		SortBy:   store.SortByGames,
+	}
+
+	params := ctx.Request.URL.Query()
+
+	if relation := params.Get(relationQueryParam); relation != "" {
+		query.Relation = store.MateRelation(relation)
+		if query.Relation != store.RelationWith && query.Relation != store.RelationAgainst && query.Relation != store.RelationAll {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "relation must be with, against or all",
+			})
+			return
+		}
+	}
+
+	if sortBy := params.Get(sortQueryParam); sortBy != "" {
+		switch sortBy {
+		case store.SortByName, store.SortByGames, store.SortByWins, store.SortByWinRate, store.SortByAvgMargin:
+			query.SortBy = sortBy
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "sort must be name, games, wins, win_rate or avg_margin",
+			})
+			return
+		}
+	}
+
+	// names are sorted ascending by default, numbers descending
+	query.Asc = query.SortBy == store.SortByName
+	if order := params.Get(orderQueryParam); order != "" {
+		if order != "asc" && order != "desc" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "order must be asc or desc",
+			})
+			return
+		}
+		query.Asc = order == "asc"
+	}
+
+	if minGames := params.Get(minGamesQueryParam); minGames != "" {
+		n, err := strconv.Atoi(minGames)
+		if err != nil || n < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "invalid minimum number of games",
+			})
+			return
+		}
+		query.MinGames = n
+	}
 
+	mates, err := store.DBSport.GetMatesMatrix(ctx, name, query, sport)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"players": players})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "can't get player's mates matrix",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"mates": mates})
 }
 
-func GetPlayer(ctx *gin.Context) {
+func GetHeadToHead(ctx *gin.Context) {
 	name := ctx.Param("name")
+	other := ctx.Param("other")
 	sportStr := ctx.Param("sport")
 
 	sport := store.Sport(sportStr)
@@ -73,7 +707,25 @@ func GetPlayer(ctx *gin.Context) {
 		return
 	}
 
-	result, err := store.DBSport.GetPlayer(ctx, name, sport)
+	lastMeetings := defaultLastMeetings
+	if last := ctx.Request.URL.Query().Get(lastQueryParam); last != "" {
+		n, err := strconv.Atoi(last)
+		if err != nil || n < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"message": "invalid number of last meetings",
+			})
+			return
+		}
+		lastMeetings = n
+	}
+
+	h2h, err := store.DBSport.GetHeadToHead(ctx, name, other, lastMeetings, sport)
+	if errors.Is(err, store.ErrSamePlayer) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "players must be different",
+		})
+		return
+	}
 	if errors.Is(err, store.ErrNoPlayerFound) {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"message": "no player found",
@@ -82,23 +734,80 @@ func GetPlayer(ctx *gin.Context) {
 	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"message": "can't find player",
+			"message": "can't get head-to-head stats",
 		})
 		return
 	}
 
-	player := &Player{}
+	ctx.JSON(http.StatusOK, gin.H{"headtohead": h2h})
+}
 
-	if err := json.Unmarshal(result, player); err != nil {
+func UpdatePlayerAttributes(ctx *gin.Context) {
+	name := ctx.Param("name")
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"message": "failed to unmarshal player",
+			"message": "store is not initialized",
+		})
+		return
+	}
+
+	var body struct {
+		Attributes map[string]string `json:"attributes"`
+	}
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid attributes data",
+		})
+		return
+	}
+
+	// players set their own attributes, admins also the ones of others and of guests
+	userName := ctx.GetString(auth.UserNameKey)
+	if name != userName && !auth.IsAdmin(userName) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"message": "player can't update the attributes of another player",
+		})
+		return
+	}
+
+	err := store.DBSport.UpdatePlayerAttributes(ctx, name, body.Attributes, sport)
+	if errors.Is(err, store.ErrNotValidAttribute) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "attribute name is not valid",
+		})
+		return
+	}
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to update player attributes",
 		})
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"player": player})
+
+	ctx.JSON(http.StatusOK, gin.H{})
 }
 
-func GetRanking(ctx *gin.Context) {
+func ClaimGuest(ctx *gin.Context) {
+	guestName := ctx.Param("name")
 	sportStr := ctx.Param("sport")
 
 	sport := store.Sport(sportStr)
@@ -107,6 +816,7 @@ func GetRanking(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotAcceptable, gin.H{
 			"message": "sport is not enabled",
 		})
+
 		return
 	}
 
@@ -114,33 +824,87 @@ func GetRanking(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "store is not initialized",
 		})
-
 		return
 	}
 
-	result, err := store.DBSport.GetRanking(ctx, sport)
+	// the guest history is claimed by the authenticated user, once an admin confirms the claim
+	playerName := ctx.GetString(auth.UserNameKey)
+
+	err := store.DBSport.ClaimGuest(ctx, guestName, playerName, sport)
 	if errors.Is(err, store.ErrNoPlayerFound) {
 		ctx.JSON(http.StatusNotFound, gin.H{
-			"message": "ranking is empty",
+			"message": "no player or guest found",
+		})
+		return
+	}
+	if errors.Is(err, store.ErrGuestClaimConflict) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"message": "guest played in a match together with the player",
+		})
+		return
+	}
+	if errors.Is(err, store.ErrGuestClaimPending) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"message": "guest is already claimed by another player",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to claim guest",
 		})
-
 		return
 	}
 
-	players := &[]Player{}
+	ctx.JSON(http.StatusAccepted, gin.H{})
+}
 
-	if err := json.Unmarshal(result, players); err != nil {
+func GetGuestClaims(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"message": "failed to unmarshal players",
+			"message": "store is not initialized",
 		})
+		return
+	}
 
+	guests, err := store.DBSport.GetGuestClaims(ctx, sport)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to retrieve guest claims",
+		})
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"ranking": players})
+
+	ctx.JSON(http.StatusOK, gin.H{"guests": guests})
 }
 
-func GetMates(ctx *gin.Context) {
-	name := ctx.Param("name")
+func ConfirmGuestClaim(ctx *gin.Context) {
+	reviewGuestClaim(ctx, func(guestName string, sport store.Sport) error {
+		return store.DBSport.ConfirmGuestClaim(ctx, guestName, sport)
+	})
+}
+
+func RejectGuestClaim(ctx *gin.Context) {
+	reviewGuestClaim(ctx, func(guestName string, sport store.Sport) error {
+		return store.DBSport.RejectGuestClaim(ctx, guestName, sport)
+	})
+}
+
+// confirm or reject the pending claim of a guest
+func reviewGuestClaim(ctx *gin.Context, review func(guestName string, sport store.Sport) error) {
+	guestName := ctx.Param("name")
 	sportStr := ctx.Param("sport")
 
 	sport := store.Sport(sportStr)
@@ -160,24 +924,33 @@ func GetMates(ctx *gin.Context) {
 		return
 	}
 
-	bF, wF, err := store.DBSport.GetMates(ctx, name, sport)
+	err := review(guestName, sport)
 	if errors.Is(err, store.ErrNoPlayerFound) {
 		ctx.JSON(http.StatusNotFound, gin.H{
-			"message": "no player found",
+			"message": "no player or guest found",
+		})
+		return
+	}
+	if errors.Is(err, store.ErrNoGuestClaim) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "guest has no pending claim",
+		})
+		return
+	}
+	if errors.Is(err, store.ErrGuestClaimConflict) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"message": "guest played in a match together with the player",
 		})
 		return
 	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"message": "can't get player's mates stats",
+			"message": "failed to review guest claim",
 		})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"bestfriend": bF,
-		"worstfoe":   wF,
-	})
+	ctx.JSON(http.StatusOK, gin.H{})
 }
 
 func GenerateBalancedTeams(ctx *gin.Context) {
@@ -202,7 +975,11 @@ func GenerateBalancedTeams(ctx *gin.Context) {
 	}
 
 	var body struct {
-		Players []string `json:"players"`
+		Players    []string        `json:"players"`
+		Guests     []Guest         `json:"guests"`
+		Rules      []AttributeRule `json:"rules"`
+		OddMode    string          `json:"odd_mode"`
+		Substitute string          `json:"substitute"`
 	}
 
 	if err := json.NewDecoder(ctx.Request.Body).Decode(&body); err != nil {
@@ -217,6 +994,12 @@ func GenerateBalancedTeams(ctx *gin.Context) {
 	var players []Player
 
 	for _, playerName := range playersNames {
+		// guests listed among players get the default rating
+		if store.IsGuest(playerName) {
+			players = append(players, Player{Name: playerName})
+			continue
+		}
+
 		result, err := store.DBSport.GetPlayer(ctx, playerName, sport)
 		if errors.Is(err, store.ErrNoPlayerFound) {
 			ctx.JSON(http.StatusNotFound, gin.H{
@@ -241,13 +1024,55 @@ func GenerateBalancedTeams(ctx *gin.Context) {
 		players = append(players, *p)
 	}
 
+	for _, guest := range body.Guests {
+		players = append(players, Player{
+			Name:       store.GuestName(guest.Name),
+			LastElo:    guest.Rating,
+			Attributes: guest.Attributes,
+		})
+	}
+
 	// turn data into storage type
 	storePlayers := make([]store.Player, len(players))
 	for i, player := range players {
 		storePlayers[i] = playerToStorePlayer(player)
 	}
 
-	team1, team2, rtValueDiff, swaps, err := store.DBSport.GenerateBalancedTeams(ctx, storePlayers, sport)
+	storeRules := make([]store.AttributeRule, len(body.Rules))
+	for i, rule := range body.Rules {
+		storeRules[i] = attributeRuleToStoreAttributeRule(rule)
+	}
+
+	oddMode := store.OddMode(body.OddMode)
+	if oddMode != "" && oddMode != store.OddBench && oddMode != store.OddShortHanded {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "odd_mode must be bench or short_handed",
+		})
+
+		return
+	}
+
+	opts := store.BalanceOptions{
+		Rules:      storeRules,
+		OddMode:    oddMode,
+		Substitute: body.Substitute,
+	}
+
+	balancedTeams, err := store.DBSport.GenerateBalancedTeams(ctx, storePlayers, opts, sport)
+	if errors.Is(err, store.ErrSubstituteNotFound) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "substitute is not among the players",
+		})
+
+		return
+	}
+	if errors.Is(err, store.ErrRulesNotSatisfied) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"message": "attribute rules can't be satisfied by the given players",
+		})
+
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusNoContent, gin.H{
 			"message": "failed to generate balanced teams",
@@ -257,20 +1082,121 @@ func GenerateBalancedTeams(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"balancedTeam1":       team1,
-		"balancedTeam2":       team2,
-		"teamValueDifference": rtValueDiff,
-		"swaps":               swaps},
+		"balancedTeam1":       balancedTeams.TeamA,
+		"balancedTeam2":       balancedTeams.TeamB,
+		"teamValueDifference": balancedTeams.TeamValueDifference,
+		"swaps":               balancedTeams.Swaps,
+		"bench":               balancedTeams.Bench,
+		"shortHanded":         balancedTeams.ShortHanded},
 	)
 }
 
+// read leaderboard, stat, minimum matches, period and tiebreakers of the ranking from query params
+func rankingQueryFromParams(params url.Values, sport store.Sport) (store.RankingQuery, error) {
+	query := store.RankingQuery{
+		By:         store.RankByElo,
+		MinMatches: defaultMinMatches,
+	}
+
+	if by := params.Get(byQueryParam); by != "" {
+		if _, ok := store.Leaderboards[by]; !ok {
+			return query, fmt.Errorf("unknown leaderboard %q", by)
+		}
+		query.By = by
+	}
+
+	// stat leaderboards rank on an individual stat of the sport
+	if query.By == store.RankByStat || query.By == store.RankByStatPerMatch {
+		stat := params.Get(statQueryParam)
+		if _, ok := store.FindStat(sport, stat); !ok {
+			return query, fmt.Errorf("unknown stat %q", stat)
+		}
+		query.Stat = stat
+	}
+
+	if minMatches := params.Get(minMatchesQueryParam); minMatches != "" {
+		n, err := strconv.Atoi(minMatches)
+		if err != nil || n < 0 {
+			return query, errors.New("invalid minimum number of matches")
+		}
+		query.MinMatches = n
+	}
+
+	if from := params.Get(fromQueryParam); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return query, errors.New("invalid from date")
+		}
+		query.From = t
+	}
+
+	if to := params.Get(toQueryParam); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return query, errors.New("invalid to date")
+		}
+		query.To = t
+	}
+
+	if !query.From.IsZero() && !query.To.IsZero() && query.From.After(query.To) {
+		return query, errors.New("from date can't be after to date")
+	}
+
+	// rating gain is computed over the last period if no date is given
+	if query.By == store.RankByRatingGain && query.From.IsZero() && query.To.IsZero() {
+		query.From = time.Now().Add(-defaultGainPeriod)
+	}
+
+	if tiebreak := params.Get(tiebreakQueryParam); tiebreak != "" {
+		for _, tiebreaker := range strings.Split(tiebreak, ",") {
+			tiebreaker = strings.TrimSpace(tiebreaker)
+			if _, ok := store.Tiebreakers[strings.TrimPrefix(tiebreaker, "-")]; !ok {
+				return query, fmt.Errorf("unknown tiebreaker %q", tiebreaker)
+			}
+			query.Tiebreakers = append(query.Tiebreakers, tiebreaker)
+		}
+	}
+
+	return query, nil
+}
+
 func playerToStorePlayer(p Player) store.Player {
 	return store.Player{
 		ID:         p.ID,
 		Name:       p.Name,
 		MatchCount: p.MatchCount,
 		WinCount:   p.WinCount,
+		DrawCount:  p.DrawCount,
 		Elo:        p.Elo,
 		LastElo:    p.LastElo,
+		Streaks:    streaksToStoreStreaks(p.Streaks),
+		Attributes: p.Attributes,
+	}
+}
+
+func streaksToStoreStreaks(s Streaks) store.Streaks {
+	return store.Streaks{
+		CurrentWin:  s.CurrentWin,
+		CurrentLoss: s.CurrentLoss,
+		LongestWin:  s.LongestWin,
+		LongestLoss: s.LongestLoss,
+	}
+}
+
+func attributeRuleToStoreAttributeRule(r AttributeRule) store.AttributeRule {
+	return store.AttributeRule{
+		Attribute:     r.Attribute,
+		Value:         r.Value,
+		MaxDifference: r.MaxDifference,
+	}
+}
+
+// check if a string is contained in a list
+func containsString(list []string, target string) bool {
+	for _, str := range list {
+		if str == target {
+			return true
+		}
 	}
+	return false
 }
@@ -1,10 +1,54 @@
 package player
 
+import "time"
+
 type Player struct {
 	ID         string    `json:"_id"`
 	Name       string    `json:"name"`
 	MatchCount int       `json:"match_count"`
 	WinCount   int       `json:"win_count"`
+	DrawCount  int       `json:"draw_count"`
 	Elo        []float64 `json:"elo"`
 	LastElo    float64   `json:"last_elo"`
+	Streaks    Streaks   `json:"streaks"`
+
+	Badges []Badge `json:"badges,omitempty"`
+
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+type RankingEntry struct {
+	Player
+	Position int     `json:"position"`
+	Value    float64 `json:"value"`
+
+	PreviousPosition int     `json:"previous_position"`
+	PositionChange   int     `json:"position_change"`
+	RatingChange     float64 `json:"rating_change"`
+}
+
+type Streaks struct {
+	CurrentWin  int `json:"current_win"`
+	CurrentLoss int `json:"current_loss"`
+	LongestWin  int `json:"longest_win"`
+	LongestLoss int `json:"longest_loss"`
+}
+
+type Badge struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	UnlockedAt  time.Time `json:"unlocked_at"`
+}
+
+type Guest struct {
+	Name       string            `json:"name"`
+	Rating     float64           `json:"rating"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+type AttributeRule struct {
+	Attribute     string `json:"attribute"`
+	Value         string `json:"value,omitempty"`
+	MaxDifference int    `json:"max_difference,omitempty"`
 }
@@ -0,0 +1,106 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fdp7/beachvolleyapp-api/store"
+)
+
+// limits of a single schedule request
+const (
+	maxCourts = 20
+	maxRounds = 50
+)
+
+func GenerateSchedule(ctx *gin.Context) {
+	sportStr := ctx.Param("sport")
+
+	sport := store.Sport(sportStr)
+	_, ok := store.EnabledSport[sport]
+	if !ok {
+		ctx.JSON(http.StatusNotAcceptable, gin.H{
+			"message": "sport is not enabled",
+		})
+
+		return
+	}
+
+	if store.DBSport == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "store is not initialized",
+		})
+
+		return
+	}
+
+	request := &ScheduleRequest{}
+	if err := ctx.BindJSON(request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "invalid session data",
+		})
+		return
+	}
+
+	if request.Courts <= 0 || request.Courts > maxCourts {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("courts must be between 1 and %d", maxCourts),
+		})
+		return
+	}
+
+	if request.Rounds <= 0 || request.Rounds > maxRounds {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("rounds must be between 1 and %d", maxRounds),
+		})
+		return
+	}
+
+	// team size defaults to the one of the sport
+	if request.TeamSize < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "team size can't be negative",
+		})
+		return
+	}
+
+	// without a given seed every request generates a different rotation
+	seed := request.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	storePlayers := make([]store.Player, len(request.Players))
+	for i, name := range request.Players {
+		storePlayers[i] = store.Player{Name: name}
+	}
+
+	schedule, err := store.DBSport.GenerateSessionSchedule(ctx, storePlayers, request.Courts, request.Rounds, request.TeamSize, seed, sport)
+	if errors.Is(err, store.ErrNoPlayerFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"message": "no player found",
+		})
+		return
+	}
+	if errors.Is(err, store.ErrNotEnoughPlayers) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "not enough players to fill a court",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"message": "failed to generate session schedule",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"rounds":   schedule.Rounds,
+		"fairness": schedule.Fairness,
+	})
+}
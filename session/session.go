@@ -0,0 +1,9 @@
+package session
+
+type ScheduleRequest struct {
+	Players  []string `json:"players"`
+	Courts   int      `json:"courts"`
+	Rounds   int      `json:"rounds"`
+	TeamSize int      `json:"team_size"`
+	Seed     int64    `json:"seed"`
+}
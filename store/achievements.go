@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Badge struct {
+	ID          string `json:"id" bson:"id"`
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description" bson:"description"`
+}
+
+type UnlockedBadge struct {
+	Badge      `bson:",inline"`
+	UnlockedAt time.Time `json:"unlocked_at" bson:"unlocked_at"`
+}
+
+// AchievementRule unlocks its badge the first time check holds after a match played by the player
+type AchievementRule struct {
+	Badge
+	check func(c achievementContext) bool
+}
+
+// stats of a player right after a match, used to evaluate achievement rules
+type achievementContext struct {
+	MatchCount       int
+	WinCount         int
+	CurrentWinStreak int
+	IsWinner         bool
+	PointsFor        int
+	PointsAgainst    int
+	// the player won against the top-ranked player (by rating) before the match
+	BeatTopRanked bool
+}
+
+var AchievementRules = []AchievementRule{
+	{
+		Badge: Badge{ID: "first_win", Name: "First win", Description: "win a match"},
+		check: func(c achievementContext) bool { return c.WinCount >= 1 },
+	},
+	{
+		Badge: Badge{ID: "win_streak_10", Name: "Unstoppable", Description: "win 10 matches in a row"},
+		check: func(c achievementContext) bool { return c.CurrentWinStreak >= 10 },
+	},
+	{
+		Badge: Badge{ID: "giant_slayer", Name: "Giant slayer", Description: "beat the top-ranked player"},
+		check: func(c achievementContext) bool { return c.BeatTopRanked },
+	},
+	{
+		Badge: Badge{ID: "centurion", Name: "Centurion", Description: "play 100 matches"},
+		check: func(c achievementContext) bool { return c.MatchCount >= 100 },
+	},
+	{
+		Badge: Badge{ID: "perfect_game", Name: "Perfect game", Description: "win a match without conceding a point"},
+		check: func(c achievementContext) bool { return c.IsWinner && c.PointsAgainst == 0 && c.PointsFor > 0 },
+	},
+}
+
+// evaluate the achievement rules for the players of a just recorded match, whose stats are already updated
+func (s *MongoSportStore) evaluateAchievements(ctx context.Context, m *Match, topRanked string, sport Sport) error {
+	for _, name := range append(append([]string{}, m.TeamA...), m.TeamB...) {
+		player, err := s.findPlayer(ctx, name, sport)
+		if err != nil {
+			return err
+		}
+
+		_, opponents, pointsFor, pointsAgainst, isWinner := matchSide(*m, name)
+
+		c := achievementContext{
+			MatchCount:       player.MatchCount,
+			WinCount:         player.WinCount,
+			CurrentWinStreak: player.Streaks.CurrentWin,
+			IsWinner:         isWinner,
+			PointsFor:        pointsFor,
+			PointsAgainst:    pointsAgainst,
+			BeatTopRanked:    isWinner && topRanked != "" && containsString(opponents, topRanked),
+		}
+
+		badges := unlockBadges(player.Badges, c, m.Date)
+		if len(badges) == len(player.Badges) {
+			continue
+		}
+
+		collection := s.playerCollectionFor(name, sport)
+
+		update := bson.M{"$set": bson.M{"badges": badges}}
+		opts := options.Update().SetUpsert(false)
+
+		if _, err := collection.UpdateOne(ctx, bson.M{"name": name}, update, opts); err != nil {
+			return fmt.Errorf("failed to update player badges: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// replay the whole match history of a sport to unlock badges from scratch, e.g. when rules change
+func (s *MongoSportStore) RecomputeAchievements(ctx context.Context, sport Sport) error {
+	dbName := s.sportDBs[sport]
+
+	matches, err := s.findMatches(ctx, bson.M{}, sport)
+	if err != nil {
+		return err
+	}
+
+	// ratings before the first match are the first elo of the players
+	initialRatings := make(map[string]float64)
+	for _, m := range matches {
+		for _, name := range append(append([]string{}, m.TeamA...), m.TeamB...) {
+			if _, ok := initialRatings[name]; ok {
+				continue
+			}
+			initialRatings[name] = defaultGuestRating
+			if player, err := s.findPlayer(ctx, name, sport); err == nil && len(player.Elo) > 0 {
+				initialRatings[name] = player.Elo[0]
+			}
+		}
+	}
+
+	badges := replayAchievements(matches, initialRatings)
+
+	for _, collectionName := range []string{s.playerCollection, s.guestCollection} {
+		collection := s.client.Database(dbName).Collection(collectionName)
+
+		if _, err := collection.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"badges": ""}}); err != nil {
+			return fmt.Errorf("failed to reset badges: %w", err)
+		}
+	}
+
+	for name, playerBadges := range badges {
+		collection := s.playerCollectionFor(name, sport)
+
+		update := bson.M{"$set": bson.M{"badges": playerBadges}}
+		opts := options.Update().SetUpsert(false)
+
+		if _, err := collection.UpdateOne(ctx, bson.M{"name": name}, update, opts); err != nil {
+			return fmt.Errorf("failed to update player badges: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// --------------------- FUNCTIONS
+
+// add to the unlocked badges the ones whose rule holds and that are not unlocked yet
+func unlockBadges(unlocked []UnlockedBadge, c achievementContext, date time.Time) []UnlockedBadge {
+	for _, rule := range AchievementRules {
+		if hasBadge(unlocked, rule.ID) || !rule.check(c) {
+			continue
+		}
+		unlocked = append(unlocked, UnlockedBadge{
+			Badge:      rule.Badge,
+			UnlockedAt: date,
+		})
+	}
+	return unlocked
+}
+
+func hasBadge(badges []UnlockedBadge, id string) bool {
+	for _, b := range badges {
+		if b.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// replay the matches (ordered by descending date) in chronological order and unlock badges for every player
+func replayAchievements(matches []Match, initialRatings map[string]float64) map[string][]UnlockedBadge {
+	type replayStats struct {
+		matchCount int
+		winCount   int
+		winStreak  int
+		rating     float64
+	}
+
+	stats := make(map[string]*replayStats)
+	badges := make(map[string][]UnlockedBadge)
+
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+		players := append(append([]string{}, m.TeamA...), m.TeamB...)
+
+		// top-ranked player before the match, among registered players with at least one match
+		topRanked := ""
+		for name, st := range stats {
+			if IsGuest(name) {
+				continue
+			}
+			if topRanked == "" || st.rating > stats[topRanked].rating || (st.rating == stats[topRanked].rating && name < topRanked) {
+				topRanked = name
+			}
+		}
+
+		for _, name := range players {
+			if _, ok := stats[name]; !ok {
+				stats[name] = &replayStats{rating: initialRatings[name]}
+			}
+			st := stats[name]
+
+			_, opponents, pointsFor, pointsAgainst, isWinner := matchSide(m, name)
+
+			st.matchCount++
+			if isWinner {
+				st.winCount++
+				st.winStreak++
+			} else {
+				st.winStreak = 0
+			}
+
+			c := achievementContext{
+				MatchCount:       st.matchCount,
+				WinCount:         st.winCount,
+				CurrentWinStreak: st.winStreak,
+				IsWinner:         isWinner,
+				PointsFor:        pointsFor,
+				PointsAgainst:    pointsAgainst,
+				BeatTopRanked:    isWinner && topRanked != "" && containsString(opponents, topRanked),
+			}
+
+			badges[name] = unlockBadges(badges[name], c, m.Date)
+		}
+
+		// ratings change only after all players have been evaluated
+		for _, name := range players {
+			stats[name].rating += m.RatingDeltas[name]
+		}
+	}
+
+	return badges
+}
@@ -0,0 +1,171 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AttributeRule asks the balancer to spread players between teams according to one of their attributes:
+// for every value of the attribute (or only for Value, if set) the number of players owning it
+// in the two teams can differ at most by MaxDifference (1 if not set, i.e. as even as possible)
+type AttributeRule struct {
+	Attribute     string `json:"attribute"`
+	Value         string `json:"value,omitempty"`
+	MaxDifference int    `json:"max_difference,omitempty"`
+}
+
+// set the attributes of a registered player or of a guest
+func (s *MongoSportStore) UpdatePlayerAttributes(ctx context.Context, playerName string, attributes map[string]string, sport Sport) error {
+	collection := s.playerCollectionFor(playerName, sport)
+
+	// attributes with an empty value are removed
+	set := bson.M{}
+	unset := bson.M{}
+	for key, value := range attributes {
+		if strings.TrimSpace(key) == "" || strings.ContainsAny(key, ".$") {
+			return ErrNotValidAttribute
+		}
+		if value == "" {
+			unset["attributes."+key] = ""
+		} else {
+			set["attributes."+key] = value
+		}
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if len(update) == 0 {
+		return nil
+	}
+
+	filter := bson.M{"name": playerName}
+	opts := options.Update().SetUpsert(false)
+
+	result, err := collection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return fmt.Errorf("failed to update player attributes: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNoPlayerFound
+	}
+
+	return nil
+}
+
+// --------------------- FUNCTIONS
+
+// Generate two teams of the same size (±1) such that the attribute rules are respected and
+// rtValue(team1) - rtValue(team2) =(about) 0
+func balanceTeamsWithRules(players map[string]float64, attributes map[string]map[string]string, rules []AttributeRule,
+	teamsValueMaxDifference float64) ([]string, []string, float64, int) {
+
+	// group players by the values of the attributes in the rules, higher rtValue first within each group
+	keys := make([]string, 0, len(players))
+	for key := range players {
+		keys = append(keys, key)
+	}
+	groupKey := func(name string) string {
+		values := make([]string, len(rules))
+		for i, rule := range rules {
+			values[i] = attributes[name][rule.Attribute]
+		}
+		return strings.Join(values, "|")
+	}
+	sort.Strings(keys)
+	sort.SliceStable(keys, func(i, j int) bool {
+		gi, gj := groupKey(keys[i]), groupKey(keys[j])
+		if gi != gj {
+			return gi < gj
+		}
+		return players[keys[i]] > players[keys[j]]
+	})
+
+	var team1 []string
+	var team2 []string
+
+	// deal every group alternately between teams, starting from the smaller team
+	toTeam1 := true
+	for i, key := range keys {
+		if i == 0 || groupKey(key) != groupKey(keys[i-1]) {
+			toTeam1 = len(team1) <= len(team2)
+		}
+		if toTeam1 {
+			team1 = append(team1, key)
+		} else {
+			team2 = append(team2, key)
+		}
+		toTeam1 = !toTeam1
+	}
+
+	// swap players while rules violations decrease or, with the same violations, the rtValue difference decreases
+	swaps := 0
+	violations := countRulesViolations(team1, team2, attributes, rules)
+	rtValueDiff := normalizedTeamsDifference(teamValue(team1, players), len(team1), teamValue(team2, players), len(team2))
+
+	for improved := true; improved && (violations > 0 || rtValueDiff >= teamsValueMaxDifference); {
+		improved = false
+		for i := range team1 {
+			for j := range team2 {
+				team1[i], team2[j] = team2[j], team1[i]
+
+				newViolations := countRulesViolations(team1, team2, attributes, rules)
+				newRtValueDiff := normalizedTeamsDifference(teamValue(team1, players), len(team1), teamValue(team2, players), len(team2))
+
+				if newViolations < violations || (newViolations == violations && newRtValueDiff < rtValueDiff) {
+					violations, rtValueDiff = newViolations, newRtValueDiff
+					improved = true
+					swaps++
+				} else {
+					team1[i], team2[j] = team2[j], team1[i]
+				}
+			}
+		}
+	}
+
+	return team1, team2, rtValueDiff, swaps
+}
+
+// sum, over rules and attribute values, of how much the teams exceed the allowed difference
+func countRulesViolations(team1 []string, team2 []string, attributes map[string]map[string]string, rules []AttributeRule) int {
+	violations := 0
+
+	for _, rule := range rules {
+		maxDifference := rule.MaxDifference
+		if maxDifference <= 0 {
+			maxDifference = 1
+		}
+
+		counts := make(map[string]int)
+		for _, name := range team1 {
+			counts[attributes[name][rule.Attribute]]++
+		}
+		for _, name := range team2 {
+			counts[attributes[name][rule.Attribute]]--
+		}
+
+		for value, difference := range counts {
+			// players without the attribute are not subject to the rule
+			if value == "" || (rule.Value != "" && value != rule.Value) {
+				continue
+			}
+			if difference < 0 {
+				difference = -difference
+			}
+			if difference > maxDifference {
+				violations += difference - maxDifference
+			}
+		}
+	}
+
+	return violations
+}
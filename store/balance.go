@@ -0,0 +1,127 @@
+package store
+
+import (
+	"sort"
+)
+
+// how to handle an odd number of players when balancing teams
+type OddMode string
+
+const (
+	// one player sits out, chosen such that the remaining teams are the most balanced
+	OddBench OddMode = "bench"
+	// everybody plays, the short-handed team is compared on its per-player value
+	OddShortHanded OddMode = "short_handed"
+)
+
+type BalanceOptions struct {
+	Rules      []AttributeRule
+	OddMode    OddMode
+	Substitute string
+}
+
+type BenchedPlayer struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+type BalancedTeams struct {
+	TeamA               []string        `json:"team_a"`
+	TeamB               []string        `json:"team_b"`
+	TeamValueDifference float64         `json:"team_value_difference"`
+	Swaps               int             `json:"swaps"`
+	Bench               []BenchedPlayer `json:"bench"`
+	ShortHanded         bool            `json:"short_handed"`
+}
+
+// --------------------- FUNCTIONS
+
+// bench the designated substitute and, if the players left are odd in bench mode, the player whose
+// exclusion gives the most balanced teams; then generate the balanced teams with the remaining players
+func balancePlayers(players map[string]float64, attributes map[string]map[string]string, opts BalanceOptions) (*BalancedTeams, error) {
+	var bench []BenchedPlayer
+
+	if opts.Substitute != "" {
+		if _, ok := players[opts.Substitute]; !ok {
+			return nil, ErrSubstituteNotFound
+		}
+
+		players = withoutPlayer(players, opts.Substitute)
+		bench = append(bench, BenchedPlayer{
+			Name:   opts.Substitute,
+			Reason: "designated substitute",
+		})
+	}
+
+	if len(players)%2 == 1 && len(players) > 1 && opts.OddMode != OddShortHanded {
+		benched := findBestBenchedPlayer(players, attributes, opts.Rules)
+
+		players = withoutPlayer(players, benched)
+		bench = append(bench, BenchedPlayer{
+			Name:   benched,
+			Reason: "odd number of players: sitting out gives the most balanced teams",
+		})
+	}
+
+	team1, team2, rtValueDiff, swaps, violations := balanceTeamsWithOptionalRules(players, attributes, opts.Rules)
+	if violations > 0 {
+		return nil, ErrRulesNotSatisfied
+	}
+
+	return &BalancedTeams{
+		TeamA:               team1,
+		TeamB:               team2,
+		TeamValueDifference: rtValueDiff,
+		Swaps:               swaps,
+		Bench:               bench,
+		ShortHanded:         len(team1) != len(team2),
+	}, nil
+}
+
+func balanceTeamsWithOptionalRules(players map[string]float64, attributes map[string]map[string]string, rules []AttributeRule) ([]string, []string, float64, int, int) {
+	if len(rules) == 0 {
+		team1, team2, rtValueDiff, swaps := balanceTeams(players, 1, 10)
+		return team1, team2, rtValueDiff, swaps, 0
+	}
+
+	team1, team2, rtValueDiff, swaps := balanceTeamsWithRules(players, attributes, rules, 1)
+
+	return team1, team2, rtValueDiff, swaps, countRulesViolations(team1, team2, attributes, rules)
+}
+
+// try benching every player and keep the one that leaves the fewest rules violations and the lowest
+// teams difference; on ties, the player with the lower rtValue sits out
+func findBestBenchedPlayer(players map[string]float64, attributes map[string]map[string]string, rules []AttributeRule) string {
+	candidates := make([]string, 0, len(players))
+	for name := range players {
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return players[candidates[i]] < players[candidates[j]]
+	})
+
+	best := ""
+	bestViolations := 0
+	bestRtValueDiff := 0.0
+
+	for _, candidate := range candidates {
+		_, _, rtValueDiff, _, violations := balanceTeamsWithOptionalRules(withoutPlayer(players, candidate), attributes, rules)
+
+		if best == "" || violations < bestViolations || (violations == bestViolations && rtValueDiff < bestRtValueDiff) {
+			best, bestViolations, bestRtValueDiff = candidate, violations, rtValueDiff
+		}
+	}
+
+	return best
+}
+
+func withoutPlayer(players map[string]float64, name string) map[string]float64 {
+	result := make(map[string]float64, len(players))
+	for key, value := range players {
+		if key != name {
+			result[key] = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBalancePlayers(t *testing.T) {
+	four := map[string]float64{"a": 10, "b": 8, "c": 6, "d": 4}
+	five := map[string]float64{"a": 10, "b": 10, "c": 10, "d": 10, "e": 1}
+
+	tests := []struct {
+		name        string
+		players     map[string]float64
+		opts        BalanceOptions
+		bench       []string
+		teamSize    int
+		shortHanded bool
+	}{
+		{"even players", four, BalanceOptions{}, nil, 2, false},
+		{"odd players benched by default", five, BalanceOptions{}, []string{"e"}, 2, false},
+		{"odd players benched", five, BalanceOptions{OddMode: OddBench}, []string{"e"}, 2, false},
+		{"odd players short-handed", five, BalanceOptions{OddMode: OddShortHanded}, nil, 3, true},
+		{"substitute leaving even players", five, BalanceOptions{Substitute: "a"}, []string{"a"}, 2, false},
+		{"substitute leaving odd players", four, BalanceOptions{Substitute: "a", OddMode: OddBench}, []string{"a", "d"}, 1, false},
+		{"substitute leaving odd players short-handed", four, BalanceOptions{Substitute: "a", OddMode: OddShortHanded}, []string{"a"}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teams, err := balancePlayers(tt.players, nil, tt.opts)
+			if err != nil {
+				t.Fatalf("balancePlayers() error = %v", err)
+			}
+
+			if len(teams.Bench) != len(tt.bench) {
+				t.Fatalf("bench = %v, want %v", teams.Bench, tt.bench)
+			}
+			for i, name := range tt.bench {
+				if teams.Bench[i].Name != name {
+					t.Errorf("benched %s, want %s", teams.Bench[i].Name, name)
+				}
+			}
+
+			if len(teams.TeamA) != tt.teamSize {
+				t.Errorf("team a has %d players, want %d", len(teams.TeamA), tt.teamSize)
+			}
+			if teams.ShortHanded != tt.shortHanded {
+				t.Errorf("short-handed = %t, want %t", teams.ShortHanded, tt.shortHanded)
+			}
+
+			// every player not benched plays in one team
+			playing := len(teams.TeamA) + len(teams.TeamB) + len(teams.Bench)
+			if playing != len(tt.players) {
+				t.Errorf("%d players placed, want %d", playing, len(tt.players))
+			}
+		})
+	}
+}
+
+func TestBalancePlayersUnknownSubstitute(t *testing.T) {
+	_, err := balancePlayers(map[string]float64{"a": 10, "b": 8}, nil, BalanceOptions{Substitute: "z"})
+	if !errors.Is(err, ErrSubstituteNotFound) {
+		t.Errorf("balancePlayers() error = %v, want %v", err, ErrSubstituteNotFound)
+	}
+}
+
+func TestBalanceTeams(t *testing.T) {
+	team1, team2, diff, _ := balanceTeams(map[string]float64{"a": 10, "b": 8, "c": 6, "d": 4}, 1, 10)
+
+	if len(team1) != 2 || len(team2) != 2 {
+		t.Fatalf("teams = %v %v, want 2 players each", team1, team2)
+	}
+	if diff != 0 {
+		t.Errorf("teams difference = %v, want 0", diff)
+	}
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// number of latest ratings shown as trend in a comparison
+const ratingTrendLength = 10
+
+const (
+	MinComparedPlayers = 2
+	MaxComparedPlayers = 6
+)
+
+type Comparison struct {
+	Players []ComparisonBlock `json:"players"`
+	// matches in which all the compared players played
+	SharedMatches int `json:"shared_matches"`
+	// head-to-head records between every pair of compared players, from the point of view of the first one
+	HeadToHead map[string]map[string]*HeadToHead `json:"head_to_head"`
+}
+
+type ComparisonBlock struct {
+	Name         string    `json:"name"`
+	Rating       float64   `json:"rating"`
+	RatingTrend  []float64 `json:"rating_trend"`
+	RatingChange float64   `json:"rating_change"`
+	MatchCount   int       `json:"match_count"`
+	WinCount     int       `json:"win_count"`
+	WinRate      float64   `json:"win_rate"`
+	Streaks      Streaks   `json:"streaks"`
+}
+
+func (s *MongoSportStore) ComparePlayers(ctx context.Context, playersNames []string, sport Sport) (*Comparison, error) {
+	if len(playersNames) < MinComparedPlayers || len(playersNames) > MaxComparedPlayers {
+		return nil, ErrNotValidComparison
+	}
+
+	var players []*Player
+	for _, name := range playersNames {
+		player, err := s.findPlayer(ctx, name, sport)
+		if err != nil {
+			return nil, err
+		}
+		players = append(players, player)
+	}
+
+	// get all matches played by any of the compared players
+	filter := bson.M{"$or": []bson.M{
+		{"team_a": bson.M{"$in": playersNames}},
+		{"team_b": bson.M{"$in": playersNames}},
+	}}
+
+	matches, err := s.findMatches(ctx, filter, sport)
+	if err != nil {
+		return nil, err
+	}
+
+	return comparePlayers(players, matches), nil
+}
+
+// --------------------- FUNCTIONS
+
+func comparePlayers(players []*Player, matches []Match) *Comparison {
+	comparison := &Comparison{
+		Players:    []ComparisonBlock{},
+		HeadToHead: make(map[string]map[string]*HeadToHead),
+	}
+
+	for _, p := range players {
+		trend := p.Elo
+		if len(trend) > ratingTrendLength {
+			trend = trend[len(trend)-ratingTrendLength:]
+		}
+
+		block := ComparisonBlock{
+			Name:        p.Name,
+			Rating:      p.LastElo,
+			RatingTrend: trend,
+			MatchCount:  p.MatchCount,
+			WinCount:    p.WinCount,
+			WinRate:     winRate(*p),
+			Streaks:     p.Streaks,
+		}
+		if len(trend) > 0 {
+			block.RatingChange = p.LastElo - trend[0]
+		}
+
+		comparison.Players = append(comparison.Players, block)
+	}
+
+	for _, m := range matches {
+		sharedByAll := true
+		for _, p := range players {
+			if !containsString(m.TeamA, p.Name) && !containsString(m.TeamB, p.Name) {
+				sharedByAll = false
+				break
+			}
+		}
+		if sharedByAll {
+			comparison.SharedMatches++
+		}
+	}
+
+	for i, p := range players {
+		comparison.HeadToHead[p.Name] = make(map[string]*HeadToHead)
+
+		for j, other := range players {
+			if i == j {
+				continue
+			}
+
+			var pairMatches []Match
+			for _, m := range matches {
+				if playedIn(m, p.Name) && playedIn(m, other.Name) {
+					pairMatches = append(pairMatches, m)
+				}
+			}
+
+			comparison.HeadToHead[p.Name][other.Name] = computeHeadToHead(pairMatches, p.Name, other.Name, 0)
+		}
+	}
+
+	return comparison
+}
+
+func playedIn(m Match, playerName string) bool {
+	return containsString(m.TeamA, playerName) || containsString(m.TeamB, playerName)
+}
@@ -0,0 +1,313 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MatchResult is the part of a match that can be edited
+type MatchResult struct {
+	TeamA  []string   `json:"team_a" bson:"team_a"`
+	TeamB  []string   `json:"team_b" bson:"team_b"`
+	ScoreA int        `json:"score_a" bson:"score_a"`
+	ScoreB int        `json:"score_b" bson:"score_b"`
+	Sets   []SetScore `json:"sets,omitempty" bson:"sets,omitempty"`
+	Date   time.Time  `json:"date" bson:"date"`
+
+	Location        string `json:"location,omitempty" bson:"location,omitempty"`
+	Notes           string `json:"notes,omitempty" bson:"notes,omitempty"`
+	DurationMinutes int    `json:"duration_minutes,omitempty" bson:"duration_minutes,omitempty"`
+
+	StatLines map[string]StatLine `json:"stat_lines,omitempty" bson:"stat_lines,omitempty"`
+}
+
+// MatchEdit keeps the result of a match before it was edited
+type MatchEdit struct {
+	EditedBy string      `json:"edited_by" bson:"edited_by"`
+	EditedAt time.Time   `json:"edited_at" bson:"edited_at"`
+	Previous MatchResult `json:"previous" bson:"previous"`
+}
+
+// correct rosters, scores, date or details of a recorded match; only its players or recorder can edit it.
+// Since ratings depend on the order of the matches, the whole history of the sport is replayed to update players stats
+func (s *MongoSportStore) EditMatch(ctx context.Context, id string, m *Match, editedBy string, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.matchCollection)
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNoMatchFound
+	}
+
+	old := &Match{}
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(old); err != nil {
+		return ErrNoMatchFound
+	}
+
+	if !canEditMatch(old, editedBy) {
+		return ErrNotAllowedToEdit
+	}
+
+	if len(m.Sets) > 0 {
+		m.ScoreA, m.ScoreB = setsWon(m.Sets)
+	}
+
+	err = s.validateMatch(ctx, m, s.matchMaxAge, sport)
+	// the date window applies only when the date is changed
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) && m.Date.Equal(old.Date) {
+		validationErr.Fields = withoutField(validationErr.Fields, "date")
+		if len(validationErr.Fields) == 0 {
+			err = nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// guests added to the roster are registered as on a new match
+	for _, name := range append(append([]string{}, m.TeamA...), m.TeamB...) {
+		if IsGuest(name) {
+			if _, err := s.getOrAddGuest(ctx, name, m.GuestRatings[name], sport); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the recorder is kept, optional fields not given are removed
+	m.RecordedBy = ""
+	set := matchDocument(m)
+
+	unset := bson.M{}
+	for _, field := range []string{"sets", "location", "notes", "duration_minutes", "stat_lines"} {
+		if _, ok := set[field]; !ok {
+			unset[field] = ""
+		}
+	}
+
+	update := bson.M{
+		"$set": set,
+		"$push": bson.M{"history": MatchEdit{
+			EditedBy: editedBy,
+			EditedAt: time.Now(),
+			Previous: MatchResult{
+				TeamA:  old.TeamA,
+				TeamB:  old.TeamB,
+				ScoreA: old.ScoreA,
+				ScoreB: old.ScoreB,
+				Sets:   old.Sets,
+				Date:   old.Date,
+
+				Location:        old.Location,
+				Notes:           old.Notes,
+				DurationMinutes: old.DurationMinutes,
+
+				StatLines: old.StatLines,
+			},
+		}},
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": objectID}, update); err != nil {
+		return fmt.Errorf("failed to update match: %w", err)
+	}
+
+	if err := s.replayMatches(ctx, sport); err != nil {
+		return fmt.Errorf("failed to replay matches: %w", err)
+	}
+
+	// players of both the old and the new roster
+	var players []string
+	for _, name := range append(append(append(append([]string{}, old.TeamA...), old.TeamB...), m.TeamA...), m.TeamB...) {
+		if !containsString(players, name) {
+			players = append(players, name)
+		}
+	}
+	if err := s.updateStreaks(ctx, players, sport); err != nil {
+		return fmt.Errorf("failed to update players streaks: %w", err)
+	}
+
+	if err := s.RecomputeAchievements(ctx, sport); err != nil {
+		return fmt.Errorf("failed to recompute achievements: %w", err)
+	}
+
+	return nil
+}
+
+// recompute from scratch, in chronological order, the stats of every player and the rating deltas
+// and surprise of every match; players start again from their first rating
+func (s *MongoSportStore) replayMatches(ctx context.Context, sport Sport) error {
+	dbName := s.sportDBs[sport]
+
+	allPlayers, err := s.findAllPlayers(ctx, sport)
+	if err != nil {
+		return err
+	}
+	players := resetPlayers(allPlayers)
+
+	matches, err := s.findMatches(ctx, bson.M{}, sport)
+	if err != nil {
+		return err
+	}
+
+	matchCollection := s.client.Database(dbName).Collection(s.matchCollection)
+
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+
+		ratingDeltas, teamAExpected := s.replayMatch(&m, players)
+		surprise, upset := computeSurprise(&m, teamAExpected)
+
+		objectID, err := primitive.ObjectIDFromHex(m.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update match %s: %w", m.ID, err)
+		}
+
+		update := bson.M{"$set": bson.M{
+			"rating_deltas": ratingDeltas,
+			"surprise":      surprise,
+			"upset":         upset,
+		}}
+
+		if _, err := matchCollection.UpdateOne(ctx, bson.M{"_id": objectID}, update); err != nil {
+			return fmt.Errorf("failed to update match rating deltas: %w", err)
+		}
+	}
+
+	for _, p := range players {
+		collection := s.playerCollectionFor(p.Name, sport)
+
+		update := bson.D{{"$set",
+			bson.D{
+				{"match_count", p.MatchCount},
+				{"win_count", p.WinCount},
+				{"draw_count", p.DrawCount},
+				{"elo", p.Elo},
+				{"last_elo", p.LastElo},
+			},
+		}}
+		opts := options.Update().SetUpsert(false)
+
+		if _, err := collection.UpdateOne(ctx, bson.M{"name": p.Name}, update, opts); err != nil {
+			return fmt.Errorf("failed to update player: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// retrieve registered players and guests
+func (s *MongoSportStore) findAllPlayers(ctx context.Context, sport Sport) ([]Player, error) {
+	dbName := s.sportDBs[sport]
+
+	var players []Player
+	for _, collectionName := range []string{s.playerCollection, s.guestCollection} {
+		collection := s.client.Database(dbName).Collection(collectionName)
+
+		results, err := collection.Find(ctx, bson.M{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve players: %w", err)
+		}
+
+		for results.Next(ctx) {
+			player := Player{}
+			if err := results.Decode(&player); err != nil {
+				return nil, fmt.Errorf("failed to retrieve player: %w", err)
+			}
+			players = append(players, player)
+		}
+	}
+
+	return players, nil
+}
+
+// apply a match to the in-memory players stats as updatePlayer does;
+// return the rating change of every player and the expected probability that team A wins
+func (s *MongoSportStore) replayMatch(m *Match, players map[string]*Player) (map[string]float64, float64) {
+	var teamARating float64
+	var teamBRating float64
+
+	for _, name := range append(append([]string{}, m.TeamA...), m.TeamB...) {
+		if _, ok := players[name]; !ok {
+			players[name] = newGuestPlayer(name, defaultGuestRating)
+		}
+	}
+	for _, name := range m.TeamA {
+		teamARating = teamARating + players[name].LastElo
+	}
+	for _, name := range m.TeamB {
+		teamBRating = teamBRating + players[name].LastElo
+	}
+
+	ratingDeltas := make(map[string]float64)
+
+	for _, name := range append(append([]string{}, m.TeamA...), m.TeamB...) {
+		p := players[name]
+		previousElo := p.LastElo
+
+		playerInTeamA := containsString(m.TeamA, name)
+		score := matchScore(m, playerInTeamA)
+
+		p.MatchCount = p.MatchCount + 1
+		if score == 1 {
+			p.WinCount = p.WinCount + 1
+		}
+		if m.IsDraw() {
+			p.DrawCount = p.DrawCount + 1
+		}
+		p.LastElo, p.Elo, _ = s.computeElo(p, teamARating, teamBRating, playerInTeamA, score, false)
+
+		ratingDeltas[name] = p.LastElo - previousElo
+	}
+
+	return ratingDeltas, expectedResultFor(teamARating, teamBRating)
+}
+
+// --------------------- FUNCTIONS
+
+// a recorded match can be edited or deleted only by its players or by who recorded it
+func canEditMatch(m *Match, playerName string) bool {
+	if playerName == "" {
+		return false
+	}
+	return m.RecordedBy == playerName || containsString(m.TeamA, playerName) || containsString(m.TeamB, playerName)
+}
+
+// players as before their first match, starting from their first rating
+func resetPlayers(players []Player) map[string]*Player {
+	reset := make(map[string]*Player, len(players))
+	for _, p := range players {
+		player := p
+
+		initialRating := defaultGuestRating
+		if len(player.Elo) > 0 {
+			initialRating = player.Elo[0]
+		}
+		player.MatchCount = 0
+		player.WinCount = 0
+		player.DrawCount = 0
+		player.Elo = []float64{initialRating}
+		player.LastElo = initialRating
+
+		reset[player.Name] = &player
+	}
+	return reset
+}
+
+func withoutField(fields []FieldError, field string) []FieldError {
+	var filtered []FieldError
+	for _, f := range fields {
+		if f.Field != field {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
+}
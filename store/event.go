@@ -0,0 +1,309 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RSVPStatus string
+
+const (
+	RSVPYes   RSVPStatus = "yes"
+	RSVPNo    RSVPStatus = "no"
+	RSVPMaybe RSVPStatus = "maybe"
+)
+
+// Event is an upcoming match players can RSVP to; once its result is recorded it turns into a match
+type Event struct {
+	ID       string    `json:"id,omitempty" bson:"_id,omitempty"`
+	Date     time.Time `json:"date" bson:"date"`
+	Location string    `json:"location" bson:"location"`
+	// maximum number of attendees, 0 if there is no limit
+	Capacity  int    `json:"capacity" bson:"capacity"`
+	Organizer string `json:"organizer" bson:"organizer"`
+	// ordered by response time
+	RSVPs []RSVP `json:"rsvps" bson:"rsvps"`
+
+	// id of the match recorded for the event, set once the result is submitted
+	MatchID string `json:"match_id,omitempty" bson:"match_id,omitempty"`
+}
+
+type RSVP struct {
+	Player      string     `json:"player" bson:"player"`
+	Status      RSVPStatus `json:"status" bson:"status"`
+	RespondedAt time.Time  `json:"responded_at" bson:"responded_at"`
+}
+
+// players who RSVPed yes, in order of response
+func (e Event) Attendees() []string {
+	attendees := []string{}
+	for _, r := range e.RSVPs {
+		if r.Status == RSVPYes {
+			attendees = append(attendees, r.Player)
+		}
+	}
+	return attendees
+}
+
+// add a new event, return its id
+func (s *MongoSportStore) AddEvent(ctx context.Context, e *Event, sport Sport) (string, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.eventCollection)
+
+	if err := checkEvent(e, time.Now()); err != nil {
+		return "", err
+	}
+
+	if e.RSVPs == nil {
+		e.RSVPs = []RSVP{}
+	}
+
+	result, err := collection.InsertOne(ctx, e)
+	if err != nil {
+		return "", fmt.Errorf("failed to add event: %w", err)
+	}
+
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// get the events whose result is not recorded yet, the next one first
+func (s *MongoSportStore) GetEvents(ctx context.Context, sport Sport) ([]Event, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.eventCollection)
+
+	filter := bson.M{"match_id": bson.M{"$exists": false}}
+	orderDate := bson.D{{"date", 1}}
+	sorting := options.Find().SetSort(orderDate)
+
+	results, err := collection.Find(ctx, filter, sorting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve events: %w", err)
+	}
+
+	events := []Event{}
+
+	for results.Next(ctx) {
+		event := Event{}
+		if err := results.Decode(&event); err != nil {
+			return nil, fmt.Errorf("failed to retrieve events: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (s *MongoSportStore) GetEvent(ctx context.Context, id string, sport Sport) (*Event, error) {
+	return s.findEvent(ctx, id, sport)
+}
+
+// set the player response to the event, replacing a previous one; a player RSVPing yes
+// to a full event is refused
+func (s *MongoSportStore) RSVPEvent(ctx context.Context, id string, playerName string, status RSVPStatus, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.eventCollection)
+
+	if _, err := s.findPlayer(ctx, playerName, sport); err != nil {
+		return err
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNoEventFound
+	}
+
+	// the previous response is replaced in a single update, so concurrent responses don't overwrite each other
+	otherRSVPs := bson.M{"$filter": bson.M{
+		"input": "$rsvps",
+		"cond":  bson.M{"$ne": bson.A{"$$this.player", bson.M{"$literal": playerName}}},
+	}}
+	rsvp := RSVP{
+		Player:      playerName,
+		Status:      status,
+		RespondedAt: time.Now(),
+	}
+
+	filter := bson.M{"_id": objectID, "match_id": bson.M{"$exists": false}}
+	if status == RSVPYes {
+		// other attendees must leave room for the player
+		otherAttendees := bson.M{"$size": bson.M{"$filter": bson.M{
+			"input": otherRSVPs,
+			"cond":  bson.M{"$eq": bson.A{"$$this.status", RSVPYes}},
+		}}}
+		filter["$expr"] = bson.M{"$or": bson.A{
+			bson.M{"$eq": bson.A{"$capacity", 0}},
+			bson.M{"$lt": bson.A{otherAttendees, "$capacity"}},
+		}}
+	}
+	update := mongo.Pipeline{
+		{{"$set", bson.M{"rsvps": bson.M{"$concatArrays": bson.A{otherRSVPs, bson.A{bson.M{"$literal": rsvp}}}}}}},
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// tell why the event was not updated
+	event, err := s.findEvent(ctx, id, sport)
+	if err != nil {
+		return err
+	}
+	if status == RSVPYes && event.MatchID == "" {
+		return ErrEventFull
+	}
+	return ErrEventClosed
+}
+
+// generate balanced teams with the players attending the event
+func (s *MongoSportStore) BalanceEvent(ctx context.Context, id string, opts BalanceOptions, sport Sport) (*BalancedTeams, error) {
+	event, err := s.findEvent(ctx, id, sport)
+	if err != nil {
+		return nil, err
+	}
+
+	attendees := event.Attendees()
+	if len(attendees) < 2 {
+		return nil, ErrNotEnoughPlayers
+	}
+
+	players := make([]Player, len(attendees))
+	for i, name := range attendees {
+		players[i] = Player{Name: name}
+	}
+
+	return s.GenerateBalancedTeams(ctx, players, opts, sport)
+}
+
+// submit the result of the event as a match, dated and located as the event; the match is pending
+// until the opposing team confirms it, as any submitted match. Return the match id
+func (s *MongoSportStore) RecordEventResult(ctx context.Context, id string, m *Match, recordedBy string, sport Sport) (string, error) {
+	event, err := s.findEvent(ctx, id, sport)
+	if err != nil {
+		return "", err
+	}
+
+	// the event is closed before submitting, so that only one result is submitted;
+	// the match id is set once the match is submitted
+	objectID, err := primitive.ObjectIDFromHex(event.ID)
+	if err != nil {
+		return "", ErrNoEventFound
+	}
+	open := bson.M{"_id": objectID, "match_id": bson.M{"$exists": false}}
+	submitting := bson.M{"_id": objectID, "match_id": ""}
+
+	if err := s.updateEvent(ctx, open, bson.M{"$set": bson.M{"match_id": ""}}, sport); err != nil {
+		return "", err
+	}
+
+	m.Date = event.Date
+	m.Location = event.Location
+	m.RecordedBy = recordedBy
+
+	matchID, err := s.submitMatch(ctx, m, recordedBy, event.ID, sport)
+	if err != nil {
+		if reopenErr := s.updateEvent(ctx, submitting, bson.M{"$unset": bson.M{"match_id": ""}}, sport); reopenErr != nil {
+			return "", fmt.Errorf("%w; %v", err, reopenErr)
+		}
+		return "", err
+	}
+
+	if err := s.updateEvent(ctx, submitting, bson.M{"$set": bson.M{"match_id": matchID}}, sport); err != nil {
+		return "", err
+	}
+
+	return matchID, nil
+}
+
+// reopen the event of a rejected or not recorded match, so that its result can be submitted again
+func (s *MongoSportStore) reopenEvent(ctx context.Context, matchID string, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.eventCollection)
+
+	filter := bson.M{"match_id": matchID}
+	update := bson.M{"$unset": bson.M{"match_id": ""}}
+
+	if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to reopen event: %w", err)
+	}
+
+	return nil
+}
+
+// link the event to its match being recorded; the event must still be linked to the match or, if it was
+// reopened, must not have another result submitted
+func (s *MongoSportStore) setEventMatch(ctx context.Context, id string, matchID string, sport Sport) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNoEventFound
+	}
+
+	filter := bson.M{
+		"_id": objectID,
+		"$or": []bson.M{{"match_id": bson.M{"$exists": false}}, {"match_id": matchID}},
+	}
+
+	return s.updateEvent(ctx, filter, bson.M{"$set": bson.M{"match_id": matchID}}, sport)
+}
+
+func (s *MongoSportStore) findEvent(ctx context.Context, id string, sport Sport) (*Event, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.eventCollection)
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNoEventFound
+	}
+
+	event := &Event{}
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(event); err != nil {
+		return nil, ErrNoEventFound
+	}
+
+	return event, nil
+}
+
+// update the event matching the filter; if its result was submitted meanwhile nothing is updated
+func (s *MongoSportStore) updateEvent(ctx context.Context, filter bson.M, update bson.M, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.eventCollection)
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrEventClosed
+	}
+
+	return nil
+}
+
+// --------------------- FUNCTIONS
+
+// an event must be in the future, at a location a match can be recorded at
+func checkEvent(e *Event, now time.Time) error {
+	switch {
+	case e.Date.IsZero():
+		return fmt.Errorf("%w: date is required", ErrNotValidEvent)
+	case e.Date.Before(now):
+		return fmt.Errorf("%w: date must be in the future", ErrNotValidEvent)
+	case strings.TrimSpace(e.Location) == "":
+		return fmt.Errorf("%w: location is required", ErrNotValidEvent)
+	case len(e.Location) > maxLocationLength:
+		return fmt.Errorf("%w: location can't be longer than %d characters", ErrNotValidEvent, maxLocationLength)
+	case e.Capacity < 0:
+		return fmt.Errorf("%w: capacity can't be negative", ErrNotValidEvent)
+	}
+	return nil
+}
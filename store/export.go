@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// pass every match of the sport, in chronological order, to export as soon as it is read
+func (s *MongoSportStore) ExportMatches(ctx context.Context, sport Sport, export func(m Match) error) error {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.matchCollection)
+
+	orderDate := bson.D{{"date", 1}}
+	sorting := options.Find().SetSort(orderDate)
+
+	results, err := collection.Find(ctx, bson.M{}, sorting)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve matches: %w", err)
+	}
+	defer results.Close(ctx)
+
+	for results.Next(ctx) {
+		match := Match{}
+		if err := results.Decode(&match); err != nil {
+			return fmt.Errorf("failed to retrieve matches: %w", err)
+		}
+		if err := export(match); err != nil {
+			return err
+		}
+	}
+
+	return results.Err()
+}
+
+// pass every player of the sport, registered players first and then guests, ordered by name,
+// to export as soon as it is read
+func (s *MongoSportStore) ExportPlayers(ctx context.Context, sport Sport, export func(p Player) error) error {
+	dbName := s.sportDBs[sport]
+
+	for _, collectionName := range []string{s.playerCollection, s.guestCollection} {
+		collection := s.client.Database(dbName).Collection(collectionName)
+
+		order := bson.D{{"name", 1}}
+		sorting := options.Find().SetSort(order)
+
+		results, err := collection.Find(ctx, bson.M{}, sorting)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve players: %w", err)
+		}
+
+		for results.Next(ctx) {
+			player := Player{}
+			if err := results.Decode(&player); err != nil {
+				results.Close(ctx)
+				return fmt.Errorf("failed to retrieve player: %w", err)
+			}
+			if err := export(player); err != nil {
+				results.Close(ctx)
+				return err
+			}
+		}
+		results.Close(ctx)
+
+		if err := results.Err(); err != nil {
+			return fmt.Errorf("failed to retrieve players: %w", err)
+		}
+	}
+
+	return nil
+}
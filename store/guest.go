@@ -0,0 +1,293 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// guests are players without an account; in matches they are referenced with this prefix
+// and their stats are kept in the guest collection, apart from registered players
+const GuestPrefix = "guest:"
+
+const defaultGuestRating = 100.0
+
+func IsGuest(name string) bool {
+	return strings.HasPrefix(name, GuestPrefix)
+}
+
+// add the guest prefix to a name, if missing
+func GuestName(name string) string {
+	if IsGuest(name) {
+		return name
+	}
+	return GuestPrefix + name
+}
+
+// ask to claim the guest history as the player's one; the history is moved once an admin confirms the claim.
+// A guest has at most one pending claim
+func (s *MongoSportStore) ClaimGuest(ctx context.Context, guestName string, playerName string, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.guestCollection)
+
+	guestName = GuestName(guestName)
+
+	if _, err := s.findPlayer(ctx, guestName, sport); err != nil {
+		return err
+	}
+	if _, err := s.findPlayer(ctx, playerName, sport); err != nil {
+		return err
+	}
+
+	if err := s.checkGuestClaim(ctx, guestName, playerName, sport); err != nil {
+		return err
+	}
+
+	// the claim is set only if nobody else claimed the guest meanwhile
+	filter := bson.M{
+		"name": guestName,
+		"$or":  []bson.M{{"claimed_by": bson.M{"$exists": false}}, {"claimed_by": playerName}},
+	}
+	update := bson.M{"$set": bson.M{"claimed_by": playerName, "claimed_at": time.Now()}}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to claim guest: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrGuestClaimPending
+	}
+
+	return nil
+}
+
+// get the guests with a pending claim, the oldest claim first
+func (s *MongoSportStore) GetGuestClaims(ctx context.Context, sport Sport) ([]Player, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.guestCollection)
+
+	filter := bson.M{"claimed_by": bson.M{"$exists": true}}
+	sorting := options.Find().SetSort(bson.D{{"claimed_at", 1}})
+
+	results, err := collection.Find(ctx, filter, sorting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve guest claims: %w", err)
+	}
+
+	guests := []Player{}
+
+	for results.Next(ctx) {
+		guest := Player{}
+		if err := results.Decode(&guest); err != nil {
+			return nil, fmt.Errorf("failed to retrieve guest claims: %w", err)
+		}
+		guests = append(guests, guest)
+	}
+
+	return guests, nil
+}
+
+// drop the pending claim of the guest
+func (s *MongoSportStore) RejectGuestClaim(ctx context.Context, guestName string, sport Sport) error {
+	_, err := s.takeGuestClaim(ctx, GuestName(guestName), sport)
+	return err
+}
+
+// move the guest history to the player who claimed it: matches, rating deltas and stat lines are renamed,
+// stats merged into the player and the guest removed
+func (s *MongoSportStore) ConfirmGuestClaim(ctx context.Context, guestName string, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	matchCollection := s.client.Database(dbName).Collection(s.matchCollection)
+
+	guestName = GuestName(guestName)
+
+	// the claim is taken first, so that it is confirmed only once
+	guest, err := s.takeGuestClaim(ctx, guestName, sport)
+	if err != nil {
+		return err
+	}
+	playerName := guest.ClaimedBy
+
+	player, err := s.findPlayer(ctx, playerName, sport)
+	if err != nil {
+		return err
+	}
+
+	// they may have played together since the claim
+	if err := s.checkGuestClaim(ctx, guestName, playerName, sport); err != nil {
+		return err
+	}
+
+	// move the guest history to the player
+	for _, team := range []string{"team_a", "team_b"} {
+		filter := bson.M{team: guestName}
+		update := bson.M{"$set": bson.M{team + ".$": playerName}}
+
+		if _, err := matchCollection.UpdateMany(ctx, filter, update); err != nil {
+			return fmt.Errorf("failed to update matches: %w", err)
+		}
+	}
+
+	for _, field := range []string{"rating_deltas", "stat_lines"} {
+		filter := bson.M{field + "." + guestName: bson.M{"$exists": true}}
+		update := bson.M{"$rename": bson.M{field + "." + guestName: field + "." + playerName}}
+
+		if _, err := matchCollection.UpdateMany(ctx, filter, update); err != nil {
+			return fmt.Errorf("failed to update matches: %w", err)
+		}
+	}
+
+	// merge stats: counts are summed, the elo trend of the guest is kept only if the player has no match yet
+	player.MatchCount = player.MatchCount + guest.MatchCount
+	player.WinCount = player.WinCount + guest.WinCount
+	player.DrawCount = player.DrawCount + guest.DrawCount
+	if player.MatchCount == guest.MatchCount {
+		player.Elo = guest.Elo
+		player.LastElo = guest.LastElo
+	}
+
+	playerCollection := s.client.Database(dbName).Collection(s.playerCollection)
+
+	playerUpdate := bson.D{{"$set",
+		bson.D{
+			{"match_count", player.MatchCount},
+			{"win_count", player.WinCount},
+			{"draw_count", player.DrawCount},
+			{"elo", player.Elo},
+			{"last_elo", player.LastElo},
+		},
+	}}
+	opts := options.Update().SetUpsert(false)
+
+	if _, err := playerCollection.UpdateOne(ctx, bson.M{"name": playerName}, playerUpdate, opts); err != nil {
+		return fmt.Errorf("failed to update player: %w", err)
+	}
+
+	guestCollection := s.client.Database(dbName).Collection(s.guestCollection)
+
+	if _, err := guestCollection.DeleteOne(ctx, bson.M{"name": guestName}); err != nil {
+		return fmt.Errorf("failed to delete guest: %w", err)
+	}
+
+	return s.updateStreaks(ctx, []string{playerName}, sport)
+}
+
+// a guest can't be claimed by a player who played in the same match
+func (s *MongoSportStore) checkGuestClaim(ctx context.Context, guestName string, playerName string, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	matchCollection := s.client.Database(dbName).Collection(s.matchCollection)
+
+	filter := bson.M{"$and": []bson.M{
+		{"$or": []bson.M{{"team_a": guestName}, {"team_b": guestName}}},
+		{"$or": []bson.M{{"team_a": playerName}, {"team_b": playerName}}},
+	}}
+	sharedMatches, err := matchCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve matches: %w", err)
+	}
+	if sharedMatches > 0 {
+		return ErrGuestClaimConflict
+	}
+
+	return nil
+}
+
+// remove the pending claim of the guest, return the guest as it was with the claim
+func (s *MongoSportStore) takeGuestClaim(ctx context.Context, guestName string, sport Sport) (*Player, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.guestCollection)
+
+	filter := bson.M{"name": guestName, "claimed_by": bson.M{"$exists": true}}
+	update := bson.M{"$unset": bson.M{"claimed_by": "", "claimed_at": ""}}
+
+	guest := &Player{}
+	err := collection.FindOneAndUpdate(ctx, filter, update).Decode(guest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := s.findPlayer(ctx, guestName, sport); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoGuestClaim
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve guest claim: %w", err)
+	}
+
+	return guest, nil
+}
+
+// --------------------- FUNCTIONS
+
+func newGuestPlayer(name string, rating float64) *Player {
+	if rating <= 0 {
+		rating = defaultGuestRating
+	}
+
+	return &Player{
+		ID:         name,
+		Name:       name,
+		MatchCount: 0,
+		WinCount:   0,
+		Elo:        []float64{rating},
+		LastElo:    rating,
+	}
+}
+
+// guests and registered players live in different collections
+func (s *MongoSportStore) playerCollectionFor(playerName string, sport Sport) *mongo.Collection {
+	dbName := s.sportDBs[sport]
+
+	if IsGuest(playerName) {
+		return s.client.Database(dbName).Collection(s.guestCollection)
+	}
+	return s.client.Database(dbName).Collection(s.playerCollection)
+}
+
+// retrieve a player (or a guest) by name
+func (s *MongoSportStore) findPlayer(ctx context.Context, playerName string, sport Sport) (*Player, error) {
+	collection := s.playerCollectionFor(playerName, sport)
+
+	filter := bson.M{"name": playerName}
+	result := collection.FindOne(ctx, filter)
+	if result == nil {
+		return nil, fmt.Errorf("failed to retrieve player: %v", result)
+	}
+
+	player := &Player{}
+	if err := result.Decode(player); err != nil {
+		return nil, ErrNoPlayerFound
+	}
+
+	return player, nil
+}
+
+// retrieve a guest, registering it with the estimated rating (or the default one) if it's the first match
+func (s *MongoSportStore) getOrAddGuest(ctx context.Context, guestName string, rating float64, sport Sport) (*Player, error) {
+	guest, err := s.findPlayer(ctx, guestName, sport)
+	if err == nil {
+		return guest, nil
+	}
+
+	guest = newGuestPlayer(guestName, rating)
+
+	collection := s.playerCollectionFor(guestName, sport)
+
+	_, err = collection.InsertOne(ctx, bson.M{
+		"_id":         guest.Name,
+		"name":        guest.Name,
+		"match_count": guest.MatchCount,
+		"win_count":   guest.WinCount,
+		"elo":         guest.Elo,
+		"last_elo":    guest.LastElo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add guest to db: %w", err)
+	}
+
+	return guest, nil
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type HeadToHead struct {
+	Player       string     `json:"player"`
+	Other        string     `json:"other"`
+	Together     PairRecord `json:"together"`
+	Against      PairRecord `json:"against"`
+	LastMeetings []Match    `json:"last_meetings"`
+}
+
+// PairRecord is the record of a player in the matches played with (or against) another player
+type PairRecord struct {
+	Matches           int     `json:"matches"`
+	Wins              int     `json:"wins"`
+	Losses            int     `json:"losses"`
+	Draws             int     `json:"draws"`
+	PointsFor         int     `json:"points_for"`
+	PointsAgainst     int     `json:"points_against"`
+	PointDifferential int     `json:"point_differential"`
+	RatingDelta       float64 `json:"rating_delta"`
+	OtherRatingDelta  float64 `json:"other_rating_delta"`
+}
+
+func (s *MongoSportStore) GetHeadToHead(ctx context.Context, playerName string, otherName string, lastMeetings int, sport Sport) (*HeadToHead, error) {
+	if playerName == otherName {
+		return nil, ErrSamePlayer
+	}
+
+	// both players must exist
+	if _, err := s.findPlayer(ctx, playerName, sport); err != nil {
+		return nil, err
+	}
+	if _, err := s.findPlayer(ctx, otherName, sport); err != nil {
+		return nil, err
+	}
+
+	// get all matches in which both players played
+	filter := bson.M{"$and": []bson.M{
+		{"$or": []bson.M{{"team_a": playerName}, {"team_b": playerName}}},
+		{"$or": []bson.M{{"team_a": otherName}, {"team_b": otherName}}},
+	}}
+
+	matches, err := s.findMatches(ctx, filter, sport)
+	if err != nil {
+		return nil, err
+	}
+
+	return computeHeadToHead(matches, playerName, otherName, lastMeetings), nil
+}
+
+// --------------------- FUNCTIONS
+
+// compute together/against records of playerName wrt otherName; matches are ordered by descending date
+func computeHeadToHead(matches []Match, playerName string, otherName string, lastMeetings int) *HeadToHead {
+	h2h := &HeadToHead{
+		Player:       playerName,
+		Other:        otherName,
+		LastMeetings: []Match{},
+	}
+
+	for _, m := range matches {
+		_, opponents, pointsFor, pointsAgainst, isWinner := matchSide(m, playerName)
+
+		record := &h2h.Together
+		if containsString(opponents, otherName) {
+			record = &h2h.Against
+		}
+
+		record.Matches++
+		switch {
+		case m.IsDraw():
+			record.Draws++
+		case isWinner:
+			record.Wins++
+		default:
+			record.Losses++
+		}
+		record.PointsFor += pointsFor
+		record.PointsAgainst += pointsAgainst
+		record.PointDifferential += pointsFor - pointsAgainst
+		record.RatingDelta += m.RatingDeltas[playerName]
+		record.OtherRatingDelta += m.RatingDeltas[otherName]
+
+		if len(h2h.LastMeetings) < lastMeetings {
+			h2h.LastMeetings = append(h2h.LastMeetings, m)
+		}
+	}
+
+	return h2h
+}
+
+// return, from the point of view of the given player: mates (player included), opponents,
+// points scored, points conceded and whether the player won the match
+func matchSide(m Match, playerName string) ([]string, []string, int, int, bool) {
+	pointsA, pointsB := m.Points()
+	if containsString(m.TeamA, playerName) {
+		return m.TeamA, m.TeamB, pointsA, pointsB, m.ScoreA > m.ScoreB
+	}
+	// nobody wins a draw
+	return m.TeamB, m.TeamA, pointsB, pointsA, m.ScoreB > m.ScoreA
+}
@@ -0,0 +1,223 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ImportRow is a match read from a line of an imported file
+type ImportRow struct {
+	Line  int
+	Match Match
+}
+
+type ImportError struct {
+	Line   int          `json:"line"`
+	Fields []FieldError `json:"errors"`
+}
+
+type ImportReport struct {
+	DryRun         bool               `json:"dry_run"`
+	Imported       int                `json:"imported"`
+	Errors         []ImportError      `json:"errors,omitempty"`
+	CreatedPlayers []string           `json:"created_players"`
+	RatingChanges  map[string]float64 `json:"rating_changes"`
+}
+
+// validate and record a batch of past matches; nothing is recorded if any row is not valid or on dry run.
+// Matches are replayed with the existing ones in chronological order, so ratings are as if recorded in time;
+// players not registered yet are added as guests, to be claimed once they sign up, only if asked;
+// otherwise they are reported as row errors
+func (s *MongoSportStore) ImportMatches(ctx context.Context, rows []ImportRow, dryRun bool, createPlayers bool, sport Sport) (*ImportReport, error) {
+	report := &ImportReport{
+		DryRun:         dryRun,
+		CreatedPlayers: []string{},
+		RatingChanges:  map[string]float64{},
+	}
+
+	existingMatches, err := s.findMatches(ctx, bson.M{}, sport)
+	if err != nil {
+		return nil, err
+	}
+
+	allPlayers, err := s.findAllPlayers(ctx, sport)
+	if err != nil {
+		return nil, err
+	}
+
+	before := make(map[string]float64, len(allPlayers))
+	for _, p := range allPlayers {
+		before[p.Name] = p.LastElo
+	}
+
+	// a match is identified by its date, compared as stored: whatever the time zone, to the millisecond
+	dates := make(map[int64]int)
+	for _, m := range existingMatches {
+		dates[m.Date.UnixMilli()] = 0
+	}
+
+	now := time.Now()
+	matches := make([]Match, 0, len(rows))
+
+	for _, row := range rows {
+		m := row.Match
+		if createPlayers {
+			asGuests(&m, before)
+		}
+		if len(m.Sets) > 0 {
+			m.ScoreA, m.ScoreB = setsWon(m.Sets)
+		}
+
+		fields := checkMatch(&m, SportMatchRules[sport], now, noMatchMaxAge)
+		if line, ok := dates[m.Date.UnixMilli()]; ok && !m.Date.IsZero() {
+			message := "a match with the same date is already recorded"
+			if line > 0 {
+				message = fmt.Sprintf("a match with the same date is on line %d", line)
+			}
+			fields = append(fields, FieldError{Field: "date", Message: message})
+		}
+		dates[m.Date.UnixMilli()] = row.Line
+
+		// guests are registered on their first match, as on a new match
+		if !createPlayers {
+			for _, team := range []struct {
+				field   string
+				players []string
+			}{{"team_a", m.TeamA}, {"team_b", m.TeamB}} {
+				for _, name := range team.players {
+					if _, ok := before[name]; !ok && !IsGuest(name) {
+						fields = append(fields, FieldError{Field: team.field, Message: fmt.Sprintf("player %s is not registered", name)})
+					}
+				}
+			}
+		}
+
+		if len(fields) > 0 {
+			report.Errors = append(report.Errors, ImportError{Line: row.Line, Fields: fields})
+			continue
+		}
+		matches = append(matches, m)
+	}
+
+	if len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	var involved []string
+	var newPlayers []*Player
+	for _, m := range matches {
+		for _, name := range append(append([]string{}, m.TeamA...), m.TeamB...) {
+			if !containsString(involved, name) {
+				involved = append(involved, name)
+			}
+			if _, ok := before[name]; ok {
+				continue
+			}
+			p := newGuestPlayer(name, m.GuestRatings[name])
+			before[name] = p.LastElo
+			allPlayers = append(allPlayers, *p)
+			newPlayers = append(newPlayers, p)
+			report.CreatedPlayers = append(report.CreatedPlayers, name)
+		}
+	}
+
+	// simulate the replay of the whole history to report the rating changes
+	players := resetPlayers(allPlayers)
+	history := append(append([]Match{}, existingMatches...), matches...)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Date.Before(history[j].Date)
+	})
+	for i := range history {
+		s.replayMatch(&history[i], players)
+	}
+
+	for name, p := range players {
+		if change := p.LastElo - before[name]; change != 0 {
+			report.RatingChanges[name] = change
+		}
+	}
+	sort.Strings(report.CreatedPlayers)
+
+	if dryRun {
+		return report, nil
+	}
+
+	for _, p := range newPlayers {
+		if _, err := s.getOrAddGuest(ctx, p.Name, p.LastElo, sport); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.insertMatches(ctx, matches, sport); err != nil {
+		return nil, err
+	}
+	report.Imported = len(matches)
+
+	if err := s.replayMatches(ctx, sport); err != nil {
+		return nil, fmt.Errorf("failed to replay matches: %w", err)
+	}
+
+	if err := s.updateStreaks(ctx, involved, sport); err != nil {
+		return nil, fmt.Errorf("failed to update players streaks: %w", err)
+	}
+
+	if err := s.RecomputeAchievements(ctx, sport); err != nil {
+		return nil, fmt.Errorf("failed to recompute achievements: %w", err)
+	}
+
+	return report, nil
+}
+
+func (s *MongoSportStore) insertMatches(ctx context.Context, matches []Match, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.matchCollection)
+
+	documents := make([]interface{}, 0, len(matches))
+	for i := range matches {
+		documents = append(documents, matchDocument(&matches[i]))
+	}
+
+	if len(documents) == 0 {
+		return nil
+	}
+
+	if _, err := collection.InsertMany(ctx, documents); err != nil {
+		return fmt.Errorf("failed to import matches: %w", err)
+	}
+
+	return nil
+}
+
+// --------------------- FUNCTIONS
+
+// reference as guests the players of the match not registered yet, in rosters and stat lines
+func asGuests(m *Match, registered map[string]float64) {
+	guestName := func(name string) string {
+		if _, ok := registered[name]; ok {
+			return name
+		}
+		return GuestName(name)
+	}
+
+	teamA := make([]string, len(m.TeamA))
+	for i, name := range m.TeamA {
+		teamA[i] = guestName(name)
+	}
+	teamB := make([]string, len(m.TeamB))
+	for i, name := range m.TeamB {
+		teamB[i] = guestName(name)
+	}
+	m.TeamA, m.TeamB = teamA, teamB
+
+	if len(m.StatLines) > 0 {
+		statLines := make(map[string]StatLine, len(m.StatLines))
+		for name, line := range m.StatLines {
+			statLines[guestName(name)] = line
+		}
+		m.StatLines = statLines
+	}
+}
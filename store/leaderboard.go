@@ -0,0 +1,478 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultMovementPeriod = 7 * 24 * time.Hour
+
+// leaderboards available for the ranking
+const (
+	RankByElo        = "elo"
+	RankByWinRate    = "win_rate"
+	RankByMatches    = "matches"
+	RankByRatingGain = "rating_gain"
+	RankByPointDiff  = "point_diff"
+	RankByStreak     = "streak"
+	// total and per-match average of an individual stat of the sport, over the matches the player has it in
+	RankByStat         = "stat"
+	RankByStatPerMatch = "stat_per_match"
+)
+
+var Leaderboards = map[string]struct{}{
+	RankByElo:        {},
+	RankByWinRate:    {},
+	RankByMatches:    {},
+	RankByRatingGain: {},
+	RankByPointDiff:  {},
+	RankByStreak:     {},
+
+	RankByStat:         {},
+	RankByStatPerMatch: {},
+}
+
+// fields usable as tiebreakers; a leading "-" sorts the field in descending order
+var Tiebreakers = map[string]struct{}{
+	"elo":            {},
+	"win_count":      {},
+	"match_count":    {},
+	"win_rate":       {},
+	"longest_streak": {},
+	"name":           {},
+}
+
+var DefaultTiebreakers = []string{"-win_count", "match_count", "name"}
+
+type RankingQuery struct {
+	By          string
+	MinMatches  int
+	From        time.Time
+	To          time.Time
+	Tiebreakers []string
+	// stat of the stat leaderboards
+	Stat string
+}
+
+type RankingEntry struct {
+	Player
+	Position int     `json:"position"`
+	Value    float64 `json:"value"`
+
+	// movement wrt the previous ranking: the snapshot taken a movement period ago or, for time-windowed
+	// rankings, the window of the same length just before; PreviousPosition is 0 if not ranked before
+	PreviousPosition int     `json:"previous_position"`
+	PositionChange   int     `json:"position_change"`
+	RatingChange     float64 `json:"rating_change"`
+}
+
+// RankingSnapshot stores all leaderboards (except the time-windowed one) at a given date
+type RankingSnapshot struct {
+	Date     time.Time                  `json:"date" bson:"date"`
+	Rankings map[string][]SnapshotEntry `json:"rankings" bson:"rankings"`
+}
+
+type SnapshotEntry struct {
+	Name     string  `json:"name" bson:"name"`
+	Position int     `json:"position" bson:"position"`
+	Value    float64 `json:"value" bson:"value"`
+	LastElo  float64 `json:"last_elo" bson:"last_elo"`
+}
+
+func (q RankingQuery) isWindowed() bool {
+	return !q.From.IsZero() || !q.To.IsZero()
+}
+
+func (s *MongoSportStore) TakeRankingSnapshot(ctx context.Context, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	snapshotCollection := s.client.Database(dbName).Collection(s.snapshotCollection)
+
+	players, err := s.findRankedPlayers(ctx, sport)
+	if err != nil {
+		return err
+	}
+
+	matches, err := s.findMatches(ctx, bson.M{}, sport)
+	if err != nil {
+		return err
+	}
+
+	snapshot := RankingSnapshot{
+		Date:     time.Now(),
+		Rankings: make(map[string][]SnapshotEntry),
+	}
+
+	for by := range Leaderboards {
+		// stat leaderboards depend on the queried stat, so they have no movement
+		if by == RankByRatingGain || by == RankByStat || by == RankByStatPerMatch {
+			continue
+		}
+
+		// every player is kept, positions are recomputed over the players of the queried ranking
+		query := RankingQuery{By: by, MinMatches: 0}
+		for _, entry := range computeRanking(players, matches, query) {
+			snapshot.Rankings[by] = append(snapshot.Rankings[by], SnapshotEntry{
+				Name:     entry.Name,
+				Position: entry.Position,
+				Value:    entry.Value,
+				LastElo:  entry.LastElo,
+			})
+		}
+	}
+
+	if _, err := snapshotCollection.InsertOne(ctx, snapshot); err != nil {
+		return fmt.Errorf("failed to add ranking snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// rank players on their whole history, with movement wrt the latest snapshot taken at least a movement period ago
+func (s *MongoSportStore) computeRankingWithSnapshot(ctx context.Context, players []Player, query RankingQuery, sport Sport) ([]RankingEntry, error) {
+	var matches []Match
+	var err error
+
+	// leaderboards based on match history need all the matches
+	if query.By == RankByRatingGain || query.By == RankByPointDiff || query.By == RankByStat || query.By == RankByStatPerMatch {
+		matches, err = s.findMatches(ctx, bson.M{}, sport)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ranking := computeRanking(players, matches, query)
+
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.snapshotCollection)
+
+	filter := bson.M{"date": bson.M{"$lte": time.Now().Add(-s.movementPeriod)}}
+	opts := options.FindOne().SetSort(bson.D{{"date", -1}})
+
+	snapshot := &RankingSnapshot{}
+	if err := collection.FindOne(ctx, filter, opts).Decode(snapshot); err != nil {
+		// no snapshot old enough yet: no movement to show
+		return ranking, nil
+	}
+
+	previous := make(map[string]SnapshotEntry)
+	for _, entry := range rerankSnapshot(snapshot.Rankings[query.By], ranking) {
+		previous[entry.Name] = entry
+	}
+
+	for i := range ranking {
+		if entry, ok := previous[ranking[i].Name]; ok {
+			ranking[i].PreviousPosition = entry.Position
+			ranking[i].PositionChange = entry.Position - ranking[i].Position
+			ranking[i].RatingChange = ranking[i].LastElo - entry.LastElo
+		}
+	}
+
+	return ranking, nil
+}
+
+// rank players on the matches played in [from, to] (to defaults to now),
+// with movement wrt the window of the same length just before
+func (s *MongoSportStore) computeWindowedRanking(ctx context.Context, players []Player, query RankingQuery, sport Sport) ([]RankingEntry, error) {
+	to := query.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	// matches are ordered by descending date: later ones are needed to get the rating at the end of the window
+	matches, err := s.findMatches(ctx, periodFilter(query.From, time.Time{}), sport)
+	if err != nil {
+		return nil, err
+	}
+
+	ranking := computeRanking(windowPlayers(players, matches, query.From, to), matchesInPeriod(matches, query.From, to), query)
+
+	// a window open on the past has no previous window
+	if query.From.IsZero() {
+		return ranking, nil
+	}
+
+	previousFrom := query.From.Add(-to.Sub(query.From))
+
+	previousMatches, err := s.findMatches(ctx, periodFilter(previousFrom, time.Time{}), sport)
+	if err != nil {
+		return nil, err
+	}
+
+	// the previous window ends right before the current one starts
+	previousTo := query.From.Add(-time.Nanosecond)
+	previousRanking := computeRanking(windowPlayers(players, previousMatches, previousFrom, previousTo),
+		matchesInPeriod(previousMatches, previousFrom, previousTo), query)
+
+	previousPositions := make(map[string]int)
+	for _, entry := range previousRanking {
+		previousPositions[entry.Name] = entry.Position
+	}
+
+	windowMatches := matchesInPeriod(matches, query.From, to)
+	for i := range ranking {
+		if position, ok := previousPositions[ranking[i].Name]; ok {
+			ranking[i].PreviousPosition = position
+			ranking[i].PositionChange = position - ranking[i].Position
+		}
+		for _, m := range windowMatches {
+			ranking[i].RatingChange += m.RatingDeltas[ranking[i].Name]
+		}
+	}
+
+	return ranking, nil
+}
+
+// --------------------- FUNCTIONS
+
+// keep the snapshot entries of the players in the ranking, positioned among them only, so that
+// movement is measured over the same players whatever the minimum number of matches
+func rerankSnapshot(entries []SnapshotEntry, ranking []RankingEntry) []SnapshotEntry {
+	ranked := make(map[string]struct{}, len(ranking))
+	for _, entry := range ranking {
+		ranked[entry.Name] = struct{}{}
+	}
+
+	sorted := make([]SnapshotEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Position < sorted[j].Position
+	})
+
+	reranked := []SnapshotEntry{}
+	for _, entry := range sorted {
+		if _, ok := ranked[entry.Name]; !ok {
+			continue
+		}
+		entry.Position = len(reranked) + 1
+		reranked = append(reranked, entry)
+	}
+
+	return reranked
+}
+
+// filter matches played in [from, to]; zero times leave the period open
+func periodFilter(from time.Time, to time.Time) bson.M {
+	date := bson.M{}
+	if !from.IsZero() {
+		date["$gte"] = from
+	}
+	if !to.IsZero() {
+		date["$lte"] = to
+	}
+	if len(date) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"date": date}
+}
+
+func movementPeriodFromConfig() time.Duration {
+	if viper.IsSet("RANKING_MOVEMENT_PERIOD") && viper.GetDuration("RANKING_MOVEMENT_PERIOD") > 0 {
+		return viper.GetDuration("RANKING_MOVEMENT_PERIOD")
+	}
+	return defaultMovementPeriod
+}
+
+// matches played in [from, to]; zero times leave the period open
+func matchesInPeriod(matches []Match, from time.Time, to time.Time) []Match {
+	var inPeriod []Match
+	for _, m := range matches {
+		if (!from.IsZero() && m.Date.Before(from)) || (!to.IsZero() && m.Date.After(to)) {
+			continue
+		}
+		inPeriod = append(inPeriod, m)
+	}
+	return inPeriod
+}
+
+// stats of the players restricted to the matches played in [from, to]; the rating is the one at the end
+// of the window, i.e. the current one minus the changes of later matches. Only players who played are kept
+func windowPlayers(players []Player, matches []Match, from time.Time, to time.Time) []Player {
+	windowMatches := matchesInPeriod(matches, from, to)
+
+	var laterMatches []Match
+	for _, m := range matches {
+		if m.Date.After(to) {
+			laterMatches = append(laterMatches, m)
+		}
+	}
+
+	var result []Player
+
+	for _, p := range players {
+		var playerMatches []Match
+		wins := 0
+		draws := 0
+		for _, m := range windowMatches {
+			if !containsString(m.TeamA, p.Name) && !containsString(m.TeamB, p.Name) {
+				continue
+			}
+			playerMatches = append(playerMatches, m)
+			if _, _, _, _, isWinner := matchSide(m, p.Name); isWinner {
+				wins++
+			}
+			if m.IsDraw() {
+				draws++
+			}
+		}
+		if len(playerMatches) == 0 {
+			continue
+		}
+
+		lastElo := p.LastElo
+		for _, m := range laterMatches {
+			lastElo -= m.RatingDeltas[p.Name]
+		}
+
+		result = append(result, Player{
+			ID:         p.ID,
+			Name:       p.Name,
+			MatchCount: len(playerMatches),
+			WinCount:   wins,
+			DrawCount:  draws,
+			LastElo:    lastElo,
+			Streaks:    computeStreaks(playerMatches, p.Name),
+			Attributes: p.Attributes,
+		})
+	}
+
+	return result
+}
+
+// order players by the value of the queried leaderboard (higher first), then by tiebreakers
+func computeRanking(players []Player, matches []Match, query RankingQuery) []RankingEntry {
+	// per-player totals over the matches of the period
+	ratingGains := make(map[string]float64)
+	pointDiffs := make(map[string]float64)
+	statTotals := make(map[string]float64)
+	statMatches := make(map[string]int)
+	for _, m := range matches {
+		for name, line := range m.StatLines {
+			if value, ok := line[query.Stat]; ok {
+				statTotals[name] += float64(value)
+				statMatches[name]++
+			}
+		}
+		for name, delta := range m.RatingDeltas {
+			ratingGains[name] += delta
+		}
+		pointsA, pointsB := m.Points()
+		for _, name := range m.TeamA {
+			pointDiffs[name] += float64(pointsA - pointsB)
+		}
+		for _, name := range m.TeamB {
+			pointDiffs[name] += float64(pointsB - pointsA)
+		}
+	}
+
+	ranking := make([]RankingEntry, 0, len(players))
+
+	for _, p := range players {
+		entry := RankingEntry{Player: p}
+
+		switch query.By {
+		case RankByWinRate:
+			if p.MatchCount < query.MinMatches {
+				continue
+			}
+			entry.Value = winRate(p)
+		case RankByMatches:
+			entry.Value = float64(p.MatchCount)
+		case RankByRatingGain:
+			entry.Value = ratingGains[p.Name]
+		case RankByPointDiff:
+			entry.Value = pointDiffs[p.Name]
+		case RankByStreak:
+			entry.Value = float64(p.Streaks.LongestWin)
+		case RankByStat:
+			if statMatches[p.Name] == 0 {
+				continue
+			}
+			entry.Value = statTotals[p.Name]
+		case RankByStatPerMatch:
+			if statMatches[p.Name] == 0 || statMatches[p.Name] < query.MinMatches {
+				continue
+			}
+			entry.Value = statTotals[p.Name] / float64(statMatches[p.Name])
+		default:
+			entry.Value = p.LastElo
+		}
+
+		ranking = append(ranking, entry)
+	}
+
+	tiebreakers := query.Tiebreakers
+	if len(tiebreakers) == 0 {
+		tiebreakers = DefaultTiebreakers
+	}
+
+	sort.SliceStable(ranking, func(i, j int) bool {
+		if ranking[i].Value != ranking[j].Value {
+			return ranking[i].Value > ranking[j].Value
+		}
+		for _, tiebreaker := range tiebreakers {
+			if c := compareOnTiebreaker(ranking[i].Player, ranking[j].Player, tiebreaker); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+
+	for i := range ranking {
+		ranking[i].Position = i + 1
+	}
+
+	return ranking
+}
+
+// return -1 if a comes before b according to the tiebreaker, 1 if it comes after, 0 if they are even
+func compareOnTiebreaker(a Player, b Player, tiebreaker string) int {
+	desc := strings.HasPrefix(tiebreaker, "-")
+	field := strings.TrimPrefix(tiebreaker, "-")
+
+	var c int
+	if field == "name" {
+		c = strings.Compare(a.Name, b.Name)
+	} else {
+		va, vb := tiebreakerValue(a, field), tiebreakerValue(b, field)
+		if va < vb {
+			c = -1
+		} else if va > vb {
+			c = 1
+		}
+	}
+
+	if desc {
+		return -c
+	}
+	return c
+}
+
+func tiebreakerValue(p Player, field string) float64 {
+	switch field {
+	case "elo":
+		return p.LastElo
+	case "win_count":
+		return float64(p.WinCount)
+	case "match_count":
+		return float64(p.MatchCount)
+	case "win_rate":
+		return winRate(p)
+	case "longest_streak":
+		return float64(p.Streaks.LongestWin)
+	}
+	return 0
+}
+
+func winRate(p Player) float64 {
+	if p.MatchCount == 0 {
+		return 0
+	}
+	return float64(p.WinCount) / float64(p.MatchCount)
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComputeRanking(t *testing.T) {
+	players := []Player{
+		{Name: "anna", LastElo: 1100, MatchCount: 10, WinCount: 6},
+		{Name: "bob", LastElo: 1100, MatchCount: 8, WinCount: 6},
+		{Name: "carl", LastElo: 1200, MatchCount: 4, WinCount: 4},
+		{Name: "dora", LastElo: 1100, MatchCount: 10, WinCount: 6},
+	}
+	matches := []Match{
+		{TeamA: []string{"anna"}, TeamB: []string{"bob"}, Sets: []SetScore{{21, 15}, {21, 19}}, ScoreA: 2},
+		{TeamA: []string{"carl"}, TeamB: []string{"dora"}, ScoreA: 21, ScoreB: 10},
+	}
+
+	tests := []struct {
+		name  string
+		query RankingQuery
+		order []string
+	}{
+		{
+			name:  "elo with default tiebreakers",
+			query: RankingQuery{By: RankByElo},
+			order: []string{"carl", "bob", "anna", "dora"},
+		},
+		{
+			name:  "elo with more matches first",
+			query: RankingQuery{By: RankByElo, Tiebreakers: []string{"-match_count", "name"}},
+			order: []string{"carl", "anna", "dora", "bob"},
+		},
+		{
+			name:  "elo by descending name",
+			query: RankingQuery{By: RankByElo, Tiebreakers: []string{"-name"}},
+			order: []string{"carl", "dora", "bob", "anna"},
+		},
+		{
+			name:  "tiebreakers applied in order",
+			query: RankingQuery{By: RankByElo, Tiebreakers: []string{"-win_rate", "-name"}},
+			order: []string{"carl", "bob", "dora", "anna"},
+		},
+		{
+			name:  "win rate with minimum matches",
+			query: RankingQuery{By: RankByWinRate, MinMatches: 5},
+			order: []string{"bob", "anna", "dora"},
+		},
+		{
+			name:  "matches played",
+			query: RankingQuery{By: RankByMatches},
+			order: []string{"anna", "dora", "bob", "carl"},
+		},
+		{
+			name:  "point differential over set points",
+			query: RankingQuery{By: RankByPointDiff},
+			order: []string{"carl", "anna", "bob", "dora"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranking := computeRanking(players, matches, tt.query)
+
+			order := make([]string, len(ranking))
+			for i, entry := range ranking {
+				order[i] = entry.Name
+				if entry.Position != i+1 {
+					t.Errorf("%s at position %d, want %d", entry.Name, entry.Position, i+1)
+				}
+			}
+			if !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("ranking = %v, want %v", order, tt.order)
+			}
+		})
+	}
+}
+
+func TestCompareOnTiebreaker(t *testing.T) {
+	a := Player{Name: "anna", WinCount: 5, MatchCount: 10}
+	b := Player{Name: "bob", WinCount: 3, MatchCount: 10}
+
+	tests := []struct {
+		tiebreaker string
+		c          int
+	}{
+		{"win_count", 1},
+		{"-win_count", -1},
+		{"match_count", 0},
+		{"name", -1},
+		{"-name", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tiebreaker, func(t *testing.T) {
+			if c := compareOnTiebreaker(a, b, tt.tiebreaker); c != tt.c {
+				t.Errorf("compareOnTiebreaker() = %d, want %d", c, tt.c)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// LocationStats is the record of a player at a court or location
+type LocationStats struct {
+	Location          string  `json:"location"`
+	Matches           int     `json:"matches"`
+	Wins              int     `json:"wins"`
+	Draws             int     `json:"draws"`
+	WinRate           float64 `json:"win_rate"`
+	PointDifferential int     `json:"point_differential"`
+	RatingDelta       float64 `json:"rating_delta"`
+}
+
+func (s *MongoSportStore) GetLocationStats(ctx context.Context, playerName string, sport Sport) ([]LocationStats, error) {
+	if _, err := s.findPlayer(ctx, playerName, sport); err != nil {
+		return nil, err
+	}
+
+	// get all matches for given player with a location
+	filter := bson.M{
+		"$or":      []bson.M{{"team_a": playerName}, {"team_b": playerName}},
+		"location": bson.M{"$exists": true},
+	}
+
+	matches, err := s.findMatches(ctx, filter, sport)
+	if err != nil {
+		return nil, err
+	}
+
+	return computeLocationStats(matches, playerName), nil
+}
+
+// --------------------- FUNCTIONS
+
+// compute the record of a player at every location, most played location first
+func computeLocationStats(matches []Match, playerName string) []LocationStats {
+	locations := make(map[string]*LocationStats)
+
+	for _, m := range matches {
+		if m.Location == "" {
+			continue
+		}
+		if _, ok := locations[m.Location]; !ok {
+			locations[m.Location] = &LocationStats{Location: m.Location}
+		}
+		stats := locations[m.Location]
+
+		_, _, pointsFor, pointsAgainst, isWinner := matchSide(m, playerName)
+
+		stats.Matches++
+		if isWinner {
+			stats.Wins++
+		}
+		if m.IsDraw() {
+			stats.Draws++
+		}
+		stats.WinRate = float64(stats.Wins) / float64(stats.Matches)
+		stats.PointDifferential += pointsFor - pointsAgainst
+		stats.RatingDelta += m.RatingDeltas[playerName]
+	}
+
+	stats := make([]LocationStats, 0, len(locations))
+	for _, l := range locations {
+		stats = append(stats, *l)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Matches != stats[j].Matches {
+			return stats[i].Matches > stats[j].Matches
+		}
+		return stats[i].Location < stats[j].Location
+	})
+
+	return stats
+}
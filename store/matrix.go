@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// relation between a player and a mate used to sort and filter the mates matrix
+type MateRelation string
+
+const (
+	RelationWith    MateRelation = "with"
+	RelationAgainst MateRelation = "against"
+	RelationAll     MateRelation = "all"
+)
+
+// fields the mates matrix can be sorted by
+const (
+	SortByName      = "name"
+	SortByGames     = "games"
+	SortByWins      = "wins"
+	SortByWinRate   = "win_rate"
+	SortByAvgMargin = "avg_margin"
+)
+
+type MatesQuery struct {
+	Relation MateRelation
+	SortBy   string
+	Asc      bool
+	MinGames int
+}
+
+type MateRecord struct {
+	Games     int     `json:"games"`
+	Wins      int     `json:"wins"`
+	Draws     int     `json:"draws"`
+	WinRate   float64 `json:"win_rate"`
+	AvgMargin float64 `json:"avg_margin"`
+
+	margin int
+}
+
+// MateStats is the record of a player when playing with and against a mate
+type MateStats struct {
+	Name    string     `json:"name"`
+	With    MateRecord `json:"with"`
+	Against MateRecord `json:"against"`
+	All     MateRecord `json:"all"`
+}
+
+func (s *MongoSportStore) GetMatesMatrix(ctx context.Context, playerName string, query MatesQuery, sport Sport) ([]MateStats, error) {
+	if _, err := s.findPlayer(ctx, playerName, sport); err != nil {
+		return nil, err
+	}
+
+	// get all matches for given player
+	filter := bson.M{"$or": []bson.M{{"team_a": playerName}, {"team_b": playerName}}}
+
+	matches, err := s.findMatches(ctx, filter, sport)
+	if err != nil {
+		return nil, err
+	}
+
+	mates := computeMatesMatrix(matches, playerName)
+
+	return sortAndFilterMates(mates, query), nil
+}
+
+// --------------------- FUNCTIONS
+
+// compute, for every other player, the record of the given player when playing with and against them
+func computeMatesMatrix(matches []Match, playerName string) []MateStats {
+	matrix := make(map[string]*MateStats)
+
+	getStats := func(name string) *MateStats {
+		if _, ok := matrix[name]; !ok {
+			matrix[name] = &MateStats{Name: name}
+		}
+		return matrix[name]
+	}
+
+	for _, m := range matches {
+		mates, opponents, pointsFor, pointsAgainst, isWinner := matchSide(m, playerName)
+
+		for _, mate := range mates {
+			if mate == playerName {
+				continue
+			}
+			stats := getStats(mate)
+			addToMateRecord(&stats.With, pointsFor-pointsAgainst, isWinner, m.IsDraw())
+			addToMateRecord(&stats.All, pointsFor-pointsAgainst, isWinner, m.IsDraw())
+		}
+		for _, opponent := range opponents {
+			stats := getStats(opponent)
+			addToMateRecord(&stats.Against, pointsFor-pointsAgainst, isWinner, m.IsDraw())
+			addToMateRecord(&stats.All, pointsFor-pointsAgainst, isWinner, m.IsDraw())
+		}
+	}
+
+	mates := make([]MateStats, 0, len(matrix))
+	for _, stats := range matrix {
+		mates = append(mates, *stats)
+	}
+
+	return mates
+}
+
+func addToMateRecord(r *MateRecord, margin int, isWinner bool, isDraw bool) {
+	r.Games++
+	if isWinner {
+		r.Wins++
+	}
+	if isDraw {
+		r.Draws++
+	}
+	r.margin += margin
+
+	r.WinRate = float64(r.Wins) / float64(r.Games)
+	r.AvgMargin = float64(r.margin) / float64(r.Games)
+}
+
+// keep mates with at least MinGames in the queried relation, sorted by the queried field;
+// ties are broken by name
+func sortAndFilterMates(mates []MateStats, query MatesQuery) []MateStats {
+	record := func(m MateStats) MateRecord {
+		switch query.Relation {
+		case RelationWith:
+			return m.With
+		case RelationAgainst:
+			return m.Against
+		default:
+			return m.All
+		}
+	}
+
+	filtered := make([]MateStats, 0, len(mates))
+	for _, m := range mates {
+		r := record(m)
+		if r.Games == 0 || r.Games < query.MinGames {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		ri, rj := record(filtered[i]), record(filtered[j])
+
+		var vi, vj float64
+		switch query.SortBy {
+		case SortByName:
+			if query.Asc {
+				return filtered[i].Name < filtered[j].Name
+			}
+			return filtered[i].Name > filtered[j].Name
+		case SortByWins:
+			vi, vj = float64(ri.Wins), float64(rj.Wins)
+		case SortByWinRate:
+			vi, vj = ri.WinRate, rj.WinRate
+		case SortByAvgMargin:
+			vi, vj = ri.AvgMargin, rj.AvgMargin
+		default:
+			vi, vj = float64(ri.Games), float64(rj.Games)
+		}
+
+		if vi == vj {
+			return filtered[i].Name < filtered[j].Name
+		}
+		if query.Asc {
+			return vi < vj
+		}
+		return vi > vj
+	})
+
+	return filtered
+}
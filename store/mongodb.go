@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -11,15 +12,24 @@ import (
 
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type MongoSportStore struct {
-	client           *mongo.Client
-	matchCollection  string
-	playerCollection string
-	sportDBs         map[Sport]string
+	client             *mongo.Client
+	matchCollection    string
+	playerCollection   string
+	guestCollection    string
+	snapshotCollection string
+	pendingCollection  string
+	eventCollection    string
+	sportDBs           map[Sport]string
+	valueModel         ValueModel
+	movementPeriod     time.Duration
+	matchMaxAge        time.Duration
+	pendingTimeout     time.Duration
 }
 
 type MongoUserStore struct {
@@ -61,10 +71,18 @@ func NewMongoDBStore(ctx context.Context, connectionURI string) (*MongoUserStore
 	}
 
 	mss := MongoSportStore{
-		client:           client,
-		matchCollection:  viper.GetString("COLLECTION_MATCH_NAME"),
-		playerCollection: viper.GetString("COLLECTION_PLAYER_NAME"),
-		sportDBs:         sportDBs,
+		client:             client,
+		matchCollection:    viper.GetString("COLLECTION_MATCH_NAME"),
+		playerCollection:   viper.GetString("COLLECTION_PLAYER_NAME"),
+		guestCollection:    viper.GetString("COLLECTION_GUEST_NAME"),
+		snapshotCollection: viper.GetString("COLLECTION_SNAPSHOT_NAME"),
+		pendingCollection:  viper.GetString("COLLECTION_PENDING_NAME"),
+		eventCollection:    viper.GetString("COLLECTION_EVENT_NAME"),
+		sportDBs:           sportDBs,
+		valueModel:         newValueModelFromConfig(),
+		movementPeriod:     movementPeriodFromConfig(),
+		matchMaxAge:        matchMaxAgeFromConfig(),
+		pendingTimeout:     pendingTimeoutFromConfig(),
 	}
 
 	return &mus, &mss, nil
@@ -112,30 +130,79 @@ func (s *MongoUserStore) AddUser(ctx context.Context, u *User) error {
 }
 
 func (s *MongoSportStore) AddMatch(ctx context.Context, m *Match, sport Sport) error {
+	return s.addMatch(ctx, m, s.matchMaxAge, sport)
+}
+
+// record a match dated within maxAge and update the stats of its players
+func (s *MongoSportStore) addMatch(ctx context.Context, m *Match, maxAge time.Duration, sport Sport) error {
 	dbName := s.sportDBs[sport]
 	collection := s.client.Database(dbName).Collection(s.matchCollection)
 
-	_, err := collection.InsertOne(ctx, bson.M{
-		"team_a":  m.TeamA,
-		"team_b":  m.TeamB,
-		"score_a": m.ScoreA,
-		"score_b": m.ScoreB,
-		"date":    m.Date,
-	})
+	// with set-by-set scoring the match score is the number of sets won
+	if len(m.Sets) > 0 {
+		m.ScoreA, m.ScoreB = setsWon(m.Sets)
+	}
+
+	if err := s.validateMatch(ctx, m, maxAge, sport); err != nil {
+		return err
+	}
+
+	// top-ranked player before the match, for achievements
+	topRanked := ""
+	rankedPlayers, err := s.findRankedPlayers(ctx, sport)
+	if err != nil {
+		return err
+	}
+	if len(rankedPlayers) > 0 {
+		topRanked = rankedPlayers[0].Name
+	}
+
+	// a confirmed pending match keeps its id
+	document := matchDocument(m)
+	if objectID, err := primitive.ObjectIDFromHex(m.ID); err == nil {
+		document["_id"] = objectID
+	}
+
+	result, err := collection.InsertOne(ctx, document)
 	if err != nil {
 		return fmt.Errorf("failed to add a new match: %w", err)
 	}
 	players := append(m.TeamA, m.TeamB...)
 	// update player stats based on played match
-	err = s.updatePlayer(ctx, m, players, sport, false)
+	ratingDeltas, teamAExpected, err := s.updatePlayer(ctx, m, players, sport, false)
 	if err != nil {
 		return fmt.Errorf("failed to update playes stats: %w", err)
 	}
 
+	// keep track of the rating change of every player in the match and of how unexpected the result was
+	surprise, upset := computeSurprise(m, teamAExpected)
+
+	filter := bson.M{"_id": result.InsertedID}
+	update := bson.M{"$set": bson.M{
+		"rating_deltas": ratingDeltas,
+		"surprise":      surprise,
+		"upset":         upset,
+	}}
+
+	_, err = collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update match rating deltas: %w", err)
+	}
+
+	err = s.updateStreaks(ctx, players, sport)
+	if err != nil {
+		return fmt.Errorf("failed to update players streaks: %w", err)
+	}
+
+	err = s.evaluateAchievements(ctx, m, topRanked, sport)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate achievements: %w", err)
+	}
+
 	return nil
 }
 
-func (s *MongoSportStore) GetMatches(ctx context.Context, player string, sport Sport) ([]byte, error) {
+func (s *MongoSportStore) GetMatches(ctx context.Context, player string, location string, sport Sport) ([]byte, error) {
 	dbName := s.sportDBs[sport]
 	collection := s.client.Database(dbName).Collection(s.matchCollection)
 
@@ -146,6 +213,9 @@ func (s *MongoSportStore) GetMatches(ctx context.Context, player string, sport S
 		filterTeamB := bson.M{"team_b": player}
 		filter = bson.M{"$or": []bson.M{filterTeamA, filterTeamB}}
 	}
+	if location != "" {
+		filter["location"] = location
+	}
 
 	orderDate := bson.D{{"date", -1}}
 	sorting := options.Find().SetSort(orderDate).SetLimit(10)
@@ -172,7 +242,7 @@ func (s *MongoSportStore) GetMatches(ctx context.Context, player string, sport S
 	return json.Marshal(matches)
 }
 
-func (s *MongoSportStore) DeleteMatch(ctx context.Context, matchDate time.Time, sport Sport) error {
+func (s *MongoSportStore) DeleteMatch(ctx context.Context, matchDate time.Time, deletedBy string, sport Sport) error {
 	dbName := s.sportDBs[sport]
 	collection := s.client.Database(dbName).Collection(s.matchCollection)
 
@@ -187,20 +257,35 @@ func (s *MongoSportStore) DeleteMatch(ctx context.Context, matchDate time.Time,
 		return ErrNoMatchFound
 	}
 
+	if !canEditMatch(match, deletedBy) {
+		return ErrNotAllowedToEdit
+	}
+
 	players := append(match.TeamA, match.TeamB...)
 
 	deletedCount, err := collection.DeleteOne(ctx, filter)
-
+	if err != nil {
+		return fmt.Errorf("failed to delete match: %w", err)
+	}
 	if deletedCount.DeletedCount == 0 {
 		return ErrNoMatchFound
 	}
+
+	// ratings depend on the order of the matches, so the history is replayed as on an edit
+	err = s.replayMatches(ctx, sport)
 	if err != nil {
-		return fmt.Errorf("failed to delete match %w, err")
+		return fmt.Errorf("failed to replay matches: %w", err)
 	}
 
-	err = s.updatePlayer(ctx, match, players, sport, true)
+	err = s.updateStreaks(ctx, players, sport)
 	if err != nil {
-		return fmt.Errorf("failed to update player stats: %w", err)
+		return fmt.Errorf("failed to update players streaks: %w", err)
+	}
+
+	// badges unlocked thanks to the deleted match are revoked
+	err = s.RecomputeAchievements(ctx, sport)
+	if err != nil {
+		return fmt.Errorf("failed to recompute achievements: %w", err)
 	}
 
 	return nil
@@ -208,6 +293,11 @@ func (s *MongoSportStore) DeleteMatch(ctx context.Context, matchDate time.Time,
 
 func (s *MongoSportStore) AddUserToSportDBs(ctx context.Context, user *User) error {
 
+	// the guest prefix tells guests apart from registered players
+	if IsGuest(user.Name) {
+		return ErrNotValidName
+	}
+
 	player := userToStorePlayer(user)
 
 	for sport := range s.sportDBs {
@@ -327,73 +417,74 @@ func (s *MongoSportStore) GetPlayer(ctx context.Context, playerName string, spor
 	return json.Marshal(player)
 }
 
-func (s *MongoSportStore) GetRanking(ctx context.Context, sport Sport) ([]byte, error) {
-	dbName := s.sportDBs[sport]
-	collection := s.client.Database(dbName).Collection(s.playerCollection)
-
-	// get all players with at least 1 match played, ordered by max(last_elo), max(win_count) and min(match_count) and alphabetical(name)
-	filter := bson.D{{"match_count", bson.D{{"$gt", 0}}}}
-	order := bson.D{{"last_elo", -1}, {"win_count", -1}, {"match_count", 1}, {"name", 1}}
-	sorting := options.Find().SetSort(order)
-
-	results, err := collection.Find(ctx, filter, sorting)
+func (s *MongoSportStore) GetRanking(ctx context.Context, query RankingQuery, sport Sport) ([]byte, error) {
+	players, err := s.findRankedPlayers(ctx, sport)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve ranking of players: %w", err)
+		return nil, err
 	}
 
-	var players []Player
-
-	for results.Next(ctx) {
-		player := Player{}
-		if err := results.Decode(&player); err != nil {
-			return nil, fmt.Errorf("failed to retrieve player: %w", err)
-		}
-		players = append(players, player)
+	var ranking []RankingEntry
+	if query.isWindowed() {
+		ranking, err = s.computeWindowedRanking(ctx, players, query, sport)
+	} else {
+		ranking, err = s.computeRankingWithSnapshot(ctx, players, query, sport)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	if len(players) == 0 {
+	if len(ranking) == 0 {
 		return nil, ErrNoPlayerFound
 	}
 
-	return json.Marshal(players)
+	return json.Marshal(ranking)
 }
 
-func (s *MongoSportStore) GenerateBalancedTeams(ctx context.Context, players []Player, sport Sport) ([]string, []string, float64, int, error) {
-	dbName := s.sportDBs[sport]
-	collection := s.client.Database(dbName).Collection(s.playerCollection)
-
+func (s *MongoSportStore) GenerateBalancedTeams(ctx context.Context, players []Player, opts BalanceOptions, sport Sport) (*BalancedTeams, error) {
 	// retrieve players stats
-	playersStats := make(map[string]float64)
+	playersList, err := s.getBalancePlayers(ctx, players, sport)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, p := range players {
+	playersStats := s.computeRtValues(playersList)
 
-		filter := bson.M{"name": p.Name}
+	playersAttributes := make(map[string]map[string]string)
+	for _, p := range playersList {
+		playersAttributes[p.Name] = p.Attributes
+	}
 
-		result := collection.FindOne(ctx, filter)
-		if result == nil {
-			return nil, nil, 0, 0, fmt.Errorf("failed to retrieve player: %v", result)
-		}
+	// generate Balanced Teams
+	balancedTeams, err := balancePlayers(playersStats, playersAttributes, opts)
+	if err != nil {
+		return nil, err
+	}
 
-		player := &Player{}
-		if err := result.Decode(player); err != nil {
-			return nil, nil, 0, 0, ErrNoPlayerFound
-		}
+	if len(balancedTeams.TeamA)+len(balancedTeams.TeamB)+len(balancedTeams.Bench) < len(playersStats) {
+		return nil, fmt.Errorf("balance teams generation failed")
+	}
 
-		// compute RealTimeValue (rtValue)
-		rtValue := computeRealTimePlayerValue(player.LastElo, player.Elo, player.MatchCount, 3)
+	return balancedTeams, nil
+}
 
-		// fill the map with names and rtValues
-		playersStats[player.Name] = rtValue
-	}
+func (s *MongoSportStore) GetPlayerValue(ctx context.Context, playerName string, sport Sport) (*PlayerValue, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.playerCollection)
 
-	// generate Balanced Teams
-	team1, team2, rtValueDiff, swaps := balanceTeams(playersStats, 1, 10)
+	// get the player specified by playerName
+	filter := bson.M{"name": playerName}
+
+	result := collection.FindOne(ctx, filter)
+	if result == nil {
+		return nil, fmt.Errorf("failed to retrieve player: %v", result)
+	}
 
-	if len(team1)+len(team2) < len(playersStats) {
-		return nil, nil, 0, 0, fmt.Errorf("balance teams generation failed")
+	player := &Player{}
+	if err := result.Decode(player); err != nil {
+		return nil, ErrNoPlayerFound
 	}
 
-	return team1, team2, rtValueDiff, swaps, nil
+	return s.valueModel.computeRealTimePlayerValue(player), nil
 }
 
 func (s *MongoSportStore) GetMates(ctx context.Context, playerName string, sport Sport) (*Mate, *Mate, error) {
@@ -434,6 +525,36 @@ func (s *MongoSportStore) GetMates(ctx context.Context, playerName string, sport
 
 // --------------------- FUNCTIONS
 
+// document of a new match; optional fields are stored only if set
+func matchDocument(m *Match) bson.M {
+	document := bson.M{
+		"team_a":  m.TeamA,
+		"team_b":  m.TeamB,
+		"score_a": m.ScoreA,
+		"score_b": m.ScoreB,
+		"date":    m.Date,
+	}
+	if len(m.Sets) > 0 {
+		document["sets"] = m.Sets
+	}
+	if m.Location != "" {
+		document["location"] = m.Location
+	}
+	if m.Notes != "" {
+		document["notes"] = m.Notes
+	}
+	if m.DurationMinutes > 0 {
+		document["duration_minutes"] = m.DurationMinutes
+	}
+	if m.RecordedBy != "" {
+		document["recorded_by"] = m.RecordedBy
+	}
+	if len(m.StatLines) > 0 {
+		document["stat_lines"] = m.StatLines
+	}
+	return document
+}
+
 func userToStorePlayer(user *User) *Player {
 	return &Player{
 		ID:         user.Name,
@@ -445,12 +566,107 @@ func userToStorePlayer(user *User) *Player {
 	}
 }
 
-// update player stats (match_count, win_count, elo) based on played or deleted match
-func (s *MongoSportStore) updatePlayer(ctx context.Context, m *Match, players []string, sport Sport, onDeletedMatch bool) error {
-
+// retrieve all players with at least 1 match played, ordered by max(last_elo), max(win_count) and min(match_count) and alphabetical(name)
+func (s *MongoSportStore) findRankedPlayers(ctx context.Context, sport Sport) ([]Player, error) {
 	dbName := s.sportDBs[sport]
 	collection := s.client.Database(dbName).Collection(s.playerCollection)
 
+	filter := bson.D{{"match_count", bson.D{{"$gt", 0}}}}
+	order := bson.D{{"last_elo", -1}, {"win_count", -1}, {"match_count", 1}, {"name", 1}}
+	sorting := options.Find().SetSort(order)
+
+	results, err := collection.Find(ctx, filter, sorting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve ranking of players: %w", err)
+	}
+
+	var players []Player
+
+	for results.Next(ctx) {
+		player := Player{}
+		if err := results.Decode(&player); err != nil {
+			return nil, fmt.Errorf("failed to retrieve player: %w", err)
+		}
+		players = append(players, player)
+	}
+
+	return players, nil
+}
+
+// retrieve all matches matching the filter, ordered by descending date
+func (s *MongoSportStore) findMatches(ctx context.Context, filter interface{}, sport Sport) ([]Match, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.matchCollection)
+
+	orderDate := bson.D{{"date", -1}}
+	sorting := options.Find().SetSort(orderDate)
+
+	results, err := collection.Find(ctx, filter, sorting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve matches: %w", err)
+	}
+
+	var matches []Match
+
+	for results.Next(ctx) {
+		match := Match{}
+		if err := results.Decode(&match); err != nil {
+			return nil, fmt.Errorf("failed to retrieve matches: %w", err)
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
+// retrieve the given players, guests never recorded get the estimated rating and attributes given in the request
+func (s *MongoSportStore) getBalancePlayers(ctx context.Context, players []Player, sport Sport) ([]*Player, error) {
+	var playersList []*Player
+
+	for _, p := range players {
+
+		player, err := s.findPlayer(ctx, p.Name, sport)
+		if errors.Is(err, ErrNoPlayerFound) && IsGuest(p.Name) {
+			player = newGuestPlayer(p.Name, p.LastElo)
+			player.Attributes = p.Attributes
+		} else if err != nil {
+			return nil, err
+		}
+
+		playersList = append(playersList, player)
+	}
+
+	return playersList, nil
+}
+
+// retrieve the given players and compute their RealTimeValue (rtValue)
+func (s *MongoSportStore) getPlayersRtValues(ctx context.Context, players []Player, sport Sport) (map[string]float64, error) {
+	playersList, err := s.getBalancePlayers(ctx, players, sport)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.computeRtValues(playersList), nil
+}
+
+func (s *MongoSportStore) computeRtValues(players []*Player) map[string]float64 {
+	playersStats := make(map[string]float64)
+
+	for _, player := range players {
+		// compute RealTimeValue (rtValue)
+		rtValue := s.valueModel.computeRealTimePlayerValue(player)
+
+		// fill the map with names and rtValues
+		playersStats[player.Name] = rtValue.Value
+	}
+
+	return playersStats
+}
+
+// update player stats (match_count, win_count, draw_count, elo) based on played or deleted match
+// return the rating change of every player and the expected probability that team A wins the match
+func (s *MongoSportStore) updatePlayer(ctx context.Context, m *Match, players []string, sport Sport, onDeletedMatch bool) (map[string]float64, float64, error) {
+
 	// check which team won
 	isTeamAWinner := false
 	if m.ScoreA > m.ScoreB {
@@ -462,16 +678,16 @@ func (s *MongoSportStore) updatePlayer(ctx context.Context, m *Match, players []
 
 	for _, p := range players {
 
-		// get player
-		filter := bson.M{"name": p}
-		result := collection.FindOne(ctx, filter)
-		if result == nil {
-			return fmt.Errorf("failed to retrieve player: %w", result)
+		// get player, guests are registered on their first match
+		var player *Player
+		var err error
+		if IsGuest(p) && !onDeletedMatch {
+			player, err = s.getOrAddGuest(ctx, p, m.GuestRatings[p], sport)
+		} else {
+			player, err = s.findPlayer(ctx, p, sport)
 		}
-
-		player := &Player{}
-		if err := result.Decode(player); err != nil {
-			return ErrNoPlayerFound
+		if err != nil {
+			return nil, 0, err
 		}
 		playersList = append(playersList, player)
 	}
@@ -493,9 +709,15 @@ func (s *MongoSportStore) updatePlayer(ctx context.Context, m *Match, players []
 		}
 	}
 
+	teamAExpected := expectedResultFor(teamARating, teamBRating)
+
+	ratingDeltas := make(map[string]float64)
+
 	// compute updated stats
 	for _, p := range playersList {
 
+		previousElo := p.LastElo
+
 		playerInTeamA := false
 		isPlayerWinner := false
 
@@ -507,10 +729,10 @@ func (s *MongoSportStore) updatePlayer(ctx context.Context, m *Match, players []
 			}
 		}
 
-		//check if player won
+		//check if player won, nobody wins a draw
 		if playerInTeamA && isTeamAWinner {
 			isPlayerWinner = true
-		} else if !playerInTeamA && !isTeamAWinner {
+		} else if !playerInTeamA && !isTeamAWinner && !m.IsDraw() {
 			isPlayerWinner = true
 		}
 
@@ -526,24 +748,35 @@ func (s *MongoSportStore) updatePlayer(ctx context.Context, m *Match, players []
 			if p.WinCount < 0 {
 				p.WinCount = 0
 			}
-			p.LastElo, p.Elo, _ = s.computeElo(p, teamARating, teamBRating, playerInTeamA, isPlayerWinner, true)
+			if m.IsDraw() && p.DrawCount > 0 {
+				p.DrawCount = p.DrawCount - 1
+			}
+			p.LastElo, p.Elo, _ = s.computeElo(p, teamARating, teamBRating, playerInTeamA, matchScore(m, playerInTeamA), true)
 		} else {
 			p.MatchCount = p.MatchCount + 1
 			if isPlayerWinner {
 				p.WinCount = p.WinCount + 1
 			}
-			p.LastElo, p.Elo, _ = s.computeElo(p, teamARating, teamBRating, playerInTeamA, isPlayerWinner, false)
+			if m.IsDraw() {
+				p.DrawCount = p.DrawCount + 1
+			}
+			p.LastElo, p.Elo, _ = s.computeElo(p, teamARating, teamBRating, playerInTeamA, matchScore(m, playerInTeamA), false)
 		}
+
+		ratingDeltas[p.Name] = p.LastElo - previousElo
 	}
 
 	// update players stats
 	for _, p := range playersList {
 
+		collection := s.playerCollectionFor(p.Name, sport)
+
 		filter := bson.M{"name": p.Name}
 		update := bson.D{{"$set",
 			bson.D{
 				{"match_count", p.MatchCount},
 				{"win_count", p.WinCount},
+				{"draw_count", p.DrawCount},
 				{"elo", p.Elo},
 				{"last_elo", p.LastElo},
 			},
@@ -552,11 +785,11 @@ func (s *MongoSportStore) updatePlayer(ctx context.Context, m *Match, players []
 
 		_, err := collection.UpdateOne(ctx, filter, update, opts)
 		if err != nil {
-			return fmt.Errorf("failed to update player: %w", err)
+			return nil, 0, fmt.Errorf("failed to update player: %w", err)
 		}
 	}
 
-	return nil
+	return ratingDeltas, teamAExpected, nil
 }
 
 // compute updated elo for player according to the following formula:
@@ -565,36 +798,26 @@ func (s *MongoSportStore) updatePlayer(ctx context.Context, m *Match, players []
 //	r^ is the updated elo
 //	r is the previous elo
 //	k = 32
-//	s = {1,0} is the assigned score for win/loss
+//	s = {1,0.5,0} is the assigned score for win/draw/loss
 //	e = 1 / ( 1 + 10 ^(( Rb - Ra) / d) ) is the expected probability that player wins the match, where
 //		R is team total elo (sum of elo per team); d = 400
 //	alpha = r / R is the player weight/importance for his team
 func (s *MongoSportStore) computeElo(p *Player, teamARating float64, teamBRating float64,
-	playerInTeamA bool, isPlayerWinner bool, onDeletedMatch bool) (float64, []float64, error) {
+	playerInTeamA bool, score float64, onDeletedMatch bool) (float64, []float64, error) {
 
 	var playerWeight float64
 	var expectedResult float64
-	var score float64
-	var d float64
 	var k float64
 
-	d = 400
 	k = 32
 
 	// calculate player weight in team [0,1] and expected match result based on team total ratings
 	if playerInTeamA {
 		playerWeight = p.LastElo / teamARating
-		expectedResult = 1 / (1 + math.Pow(10, (teamBRating-teamARating)/d))
+		expectedResult = expectedResultFor(teamARating, teamBRating)
 	} else {
 		playerWeight = p.LastElo / teamBRating
-		expectedResult = 1 / (1 + math.Pow(10, (teamARating-teamBRating)/d))
-	}
-
-	// define score values
-	if isPlayerWinner {
-		score = 1
-	} else {
-		score = 0
+		expectedResult = expectedResultFor(teamBRating, teamARating)
 	}
 
 	// compute updated player rating
@@ -613,6 +836,24 @@ func (s *MongoSportStore) computeElo(p *Player, teamARating float64, teamBRating
 	return p.LastElo, p.Elo, nil
 }
 
+// score of a player in the match: 1 for a win, 0.5 for a draw, 0 for a loss
+func matchScore(m *Match, playerInTeamA bool) float64 {
+	switch {
+	case m.IsDraw():
+		return 0.5
+	case playerInTeamA == (m.ScoreA > m.ScoreB):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// expected probability that a team wins the match: e = 1 / ( 1 + 10 ^(( Rb - Ra) / d) ), with d = 400
+func expectedResultFor(teamRating float64, otherTeamRating float64) float64 {
+	d := 400.0
+	return 1 / (1 + math.Pow(10, (otherTeamRating-teamRating)/d))
+}
+
 // Generate two teams such that : rtValue(team1) - rtValue(team2) =(about) 0
 func balanceTeams(players map[string]float64, teamsValueMaxDifference float64, maxSwaps int) ([]string, []string, float64, int) {
 	// sort players from higher to lower rtValue
@@ -629,10 +870,14 @@ func balanceTeams(players map[string]float64, teamsValueMaxDifference float64, m
 	var team1rtValue float64
 	var team2rtValue float64
 
-	// make 2 teams from the sorted list and compute team rtValues
+	// with an odd number of players team1 is the one with the extra player
+	team1Size := (len(keys) + 1) / 2
+	team2Size := len(keys) / 2
+
+	// make 2 teams of fixed size from the sorted list and compute team rtValues
 	for _, key := range keys {
 		playerRtValue := players[key]
-		if team1rtValue <= team2rtValue {
+		if len(team2) >= team2Size || (team1rtValue <= team2rtValue && len(team1) < team1Size) {
 			team1 = append(team1, key)
 			team1rtValue += playerRtValue
 		} else {
@@ -643,9 +888,9 @@ func balanceTeams(players map[string]float64, teamsValueMaxDifference float64, m
 
 	// attempt to swap players to minimize the difference between teams' rtValues until threshold teamsValueMaxDifference or maxSwaps is reached
 	swaps := 0
-	rtValueDiff := math.Abs(team1rtValue - team2rtValue)
+	rtValueDiff := normalizedTeamsDifference(team1rtValue, len(team1), team2rtValue, len(team2))
 	for rtValueDiff >= teamsValueMaxDifference {
-		if swaps >= maxSwaps {
+		if swaps >= maxSwaps || len(team2) == 0 {
 			break
 		} else {
 			maxIdx, minIdx := findPlayersMaxMinValue(team1, team2, players)
@@ -657,14 +902,14 @@ func balanceTeams(players map[string]float64, teamsValueMaxDifference float64, m
 			team2rtValueNew := team2rtValue + players[player1] - players[player2]
 
 			// if no improvement, that's already the best balance between teams
-			if rtValueDiff < math.Abs(team1rtValueNew-team2rtValueNew) {
+			if rtValueDiff < normalizedTeamsDifference(team1rtValueNew, len(team1), team2rtValueNew, len(team2)) {
 				break
 			} else {
 				// swap players, update team values and their difference
 				team1[maxIdx], team2[minIdx] = player2, player1
 
 				team1rtValue, team2rtValue = team1rtValueNew, team2rtValueNew
-				rtValueDiff = math.Abs(team1rtValue - team2rtValue)
+				rtValueDiff = normalizedTeamsDifference(team1rtValue, len(team1), team2rtValue, len(team2))
 
 				swaps++
 			}
@@ -674,6 +919,18 @@ func balanceTeams(players map[string]float64, teamsValueMaxDifference float64, m
 	return team1, team2, rtValueDiff, swaps
 }
 
+// difference between teams' rtValues, where the value of a short-handed team is scaled up
+// to the size of the other team (i.e. teams are compared on their per-player value)
+func normalizedTeamsDifference(team1rtValue float64, team1Size int, team2rtValue float64, team2Size int) float64 {
+	if team1Size == team2Size || team1Size == 0 || team2Size == 0 {
+		return math.Abs(team1rtValue - team2rtValue)
+	}
+
+	maxSize := math.Max(float64(team1Size), float64(team2Size))
+
+	return math.Abs(team1rtValue/float64(team1Size)*maxSize - team2rtValue/float64(team2Size)*maxSize)
+}
+
 // find the higher value for team1 and lower value for team2
 // return the indexes of the players owing such values
 func findPlayersMaxMinValue(team1 []string, team2 []string, players map[string]float64) (int, int) {
@@ -699,35 +956,6 @@ func findPlayersMaxMinValue(team1 []string, team2 []string, players map[string]f
 	return maxIdx, minIdx
 }
 
-// compute RealTimeValue for a player considering :
-// last elo, historic avg, latest avg, match played, latest period to analyze
-func computeRealTimePlayerValue(lastElo float64, elo []float64, matchCount int, latestPeriod int) float64 {
-	var rtValue float64
-	e := make([]float64, latestPeriod) // sub-elo trend to analyze
-
-	if len(elo) > latestPeriod {
-		// starting from second-latest match fill the sub-elo trend
-		for i := 1; i <= latestPeriod; i++ {
-			e[i-1] = elo[len(elo)-1-i]
-		}
-
-		// compute historic and latest elo average
-		totalAvg := computeAvg(elo)
-		latestAvg := computeAvg(e)
-
-		// RealTimeVPlayerValue computed as
-		// last elo
-		// adding bonus/malus based on latest period performance
-		// weighted on number of games played wrt the considered latest period length
-		// such that the higher the matchCount, the higher the confidence in bonus/malus, the higher the bonus/malus incidence in RTV
-		rtValue = lastElo + ((latestAvg - totalAvg) / float64(matchCount/(matchCount-latestPeriod)))
-	} else {
-		rtValue = lastElo
-	}
-
-	return rtValue
-}
-
 // compute average of values in a slice
 func computeAvg(n []float64) float64 {
 	var sum float64
@@ -804,9 +1032,16 @@ func getBestFriendAndWorstFoe(matches []Match, playerName string) (*Mate, *Mate)
 	// find worstFoe: the player with whom the player lost more matches when playing against
 	worstFoe, matchCountLost := findMaxOccurrences(lostAgainst)
 
-	// for bestFriend/worstFoe find total number of matches played together/against
-	matchesWithBestFriend := countOccurrences(append(wonTogether, lostAgainst...), bestFriend)
-	matchesWithWorstFriend := countOccurrences(append(wonTogether, lostAgainst...), worstFoe)
+	// for bestFriend/worstFoe find total number of matches played together/against, won or lost
+	var matchesWithBestFriend, matchesWithWorstFriend int
+	for _, mate := range computeMatesMatrix(matches, playerName) {
+		if mate.Name == bestFriend {
+			matchesWithBestFriend = mate.With.Games
+		}
+		if mate.Name == worstFoe {
+			matchesWithWorstFriend = mate.Against.Games
+		}
+	}
 
 	bF := &Mate{
 		Name:              bestFriend,
@@ -829,6 +1064,11 @@ func getMatesStats(matches []Match, playerName string, wonTogether []string, los
 		var friends []string
 		var foes []string
 
+		// nobody won or lost a draw
+		if m.IsDraw() {
+			continue
+		}
+
 		isWinner := false
 		if containsString(m.TeamA, playerName) {
 			// teamA is friend
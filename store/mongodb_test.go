@@ -0,0 +1,45 @@
+package store
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMatchScore(t *testing.T) {
+	tests := []struct {
+		name          string
+		match         Match
+		playerInTeamA bool
+		score         float64
+	}{
+		{"team a wins, player in team a", Match{ScoreA: 21, ScoreB: 15}, true, 1},
+		{"team a wins, player in team b", Match{ScoreA: 21, ScoreB: 15}, false, 0},
+		{"team b wins, player in team b", Match{ScoreA: 18, ScoreB: 21}, false, 1},
+		{"team b wins, player in team a", Match{ScoreA: 18, ScoreB: 21}, true, 0},
+		{"draw, player in team a", Match{ScoreA: 80, ScoreB: 80}, true, 0.5},
+		{"draw, player in team b", Match{ScoreA: 80, ScoreB: 80}, false, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if score := matchScore(&tt.match, tt.playerInTeamA); score != tt.score {
+				t.Errorf("matchScore() = %v, want %v", score, tt.score)
+			}
+		})
+	}
+}
+
+func TestExpectedResultFor(t *testing.T) {
+	if e := expectedResultFor(1000, 1000); e != 0.5 {
+		t.Errorf("expected result of even teams = %v, want 0.5", e)
+	}
+
+	// the expected results of the two teams add up to 1
+	e := expectedResultFor(1200, 1000) + expectedResultFor(1000, 1200)
+	if math.Abs(e-1) > 1e-9 {
+		t.Errorf("expected results add up to %v, want 1", e)
+	}
+	if expectedResultFor(1200, 1000) <= 0.5 {
+		t.Error("stronger team is not expected to win")
+	}
+}
@@ -0,0 +1,330 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultPendingTimeout = 48 * time.Hour
+
+// a pending match being recorded is claimed for this long; past it, a claim left by a failure can be taken again
+const pendingClaimTimeout = 10 * time.Minute
+
+// PendingMatch is a submitted match waiting for the opposing team to confirm it;
+// ratings change only once the match is confirmed
+type PendingMatch struct {
+	Match `bson:",inline"`
+
+	// guest ratings are kept until the match is recorded
+	GuestRatings map[string]float64 `json:"guest_ratings,omitempty" bson:"guest_ratings,omitempty"`
+
+	SubmittedBy string    `json:"submitted_by" bson:"submitted_by"`
+	SubmittedAt time.Time `json:"submitted_at" bson:"submitted_at"`
+	// players allowed to confirm or reject the match
+	Confirmers []string `json:"confirmers" bson:"confirmers"`
+
+	// set while the match is being recorded, so that it is recorded only once
+	ConfirmingAt *time.Time `json:"-" bson:"confirming_at,omitempty"`
+
+	// event the match is the result of, if any
+	EventID string `json:"event_id,omitempty" bson:"event_id,omitempty"`
+}
+
+// validate a match and keep it pending until confirmation, return its id
+func (s *MongoSportStore) SubmitMatch(ctx context.Context, m *Match, submittedBy string, sport Sport) (string, error) {
+	return s.submitMatch(ctx, m, submittedBy, "", sport)
+}
+
+func (s *MongoSportStore) submitMatch(ctx context.Context, m *Match, submittedBy string, eventID string, sport Sport) (string, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.pendingCollection)
+
+	if len(m.Sets) > 0 {
+		m.ScoreA, m.ScoreB = setsWon(m.Sets)
+	}
+
+	if err := s.validateMatch(ctx, m, s.matchMaxAge, sport); err != nil {
+		return "", err
+	}
+
+	pending := PendingMatch{
+		Match:        *m,
+		GuestRatings: m.GuestRatings,
+		SubmittedBy:  submittedBy,
+		SubmittedAt:  time.Now(),
+		Confirmers:   confirmers(m, submittedBy),
+		EventID:      eventID,
+	}
+
+	result, err := collection.InsertOne(ctx, pending)
+	if err != nil {
+		return "", fmt.Errorf("failed to add pending match: %w", err)
+	}
+
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// get the pending matches the player submitted or can confirm
+func (s *MongoSportStore) GetPendingMatches(ctx context.Context, playerName string, sport Sport) ([]PendingMatch, error) {
+	filter := bson.M{"$or": []bson.M{{"submitted_by": playerName}, {"confirmers": playerName}}}
+
+	return s.findPendingMatches(ctx, filter, sport)
+}
+
+// record a pending match, confirmed by a player of the opposing team
+func (s *MongoSportStore) ConfirmMatch(ctx context.Context, id string, playerName string, sport Sport) error {
+	pending, err := s.findPendingMatch(ctx, id, sport)
+	if err != nil {
+		return err
+	}
+
+	if !containsString(pending.Confirmers, playerName) {
+		return ErrNotAllowedToConfirm
+	}
+
+	return s.recordPendingMatch(ctx, pending, sport)
+}
+
+// discard a pending match; the submitter can withdraw it too. The result of an event can then be submitted again
+func (s *MongoSportStore) RejectMatch(ctx context.Context, id string, playerName string, sport Sport) error {
+	pending, err := s.findPendingMatch(ctx, id, sport)
+	if err != nil {
+		return err
+	}
+
+	if !containsString(pending.Confirmers, playerName) && pending.SubmittedBy != playerName {
+		return ErrNotAllowedToConfirm
+	}
+
+	// a match being recorded can't be rejected
+	if err := s.claimPendingMatch(ctx, pending, sport); err != nil {
+		return err
+	}
+	if err := s.deletePendingMatch(ctx, pending, sport); err != nil {
+		return err
+	}
+
+	return s.reopenEvent(ctx, pending.ID, sport)
+}
+
+// record the pending matches nobody confirmed or rejected within the timeout; a match that can't be recorded
+// stays pending and doesn't stop the others
+func (s *MongoSportStore) ConfirmExpiredMatches(ctx context.Context, sport Sport) error {
+	filter := bson.M{"submitted_at": bson.M{"$lte": time.Now().Add(-s.pendingTimeout)}}
+
+	expired, err := s.findPendingMatches(ctx, filter, sport)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+
+	// oldest first, as they would have been recorded
+	for i := len(expired) - 1; i >= 0; i-- {
+		err := s.recordPendingMatch(ctx, &expired[i], sport)
+		// confirmed or rejected meanwhile, or superseded by another result of its event
+		if errors.Is(err, ErrNoMatchFound) || errors.Is(err, ErrEventClosed) {
+			continue
+		}
+		if err != nil {
+			log.Printf("failed to confirm expired match %s: %s\n", expired[i].ID, err.Error())
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to confirm %d of %d expired matches", failed, len(expired))
+	}
+
+	return nil
+}
+
+func pendingTimeoutFromConfig() time.Duration {
+	if viper.IsSet("PENDING_MATCH_TIMEOUT") && viper.GetDuration("PENDING_MATCH_TIMEOUT") > 0 {
+		return viper.GetDuration("PENDING_MATCH_TIMEOUT")
+	}
+	return defaultPendingTimeout
+}
+
+func (s *MongoSportStore) findPendingMatch(ctx context.Context, id string, sport Sport) (*PendingMatch, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.pendingCollection)
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNoMatchFound
+	}
+
+	pending := &PendingMatch{}
+	if err := collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(pending); err != nil {
+		return nil, ErrNoMatchFound
+	}
+
+	return pending, nil
+}
+
+// retrieve pending matches, ordered by descending submission time
+func (s *MongoSportStore) findPendingMatches(ctx context.Context, filter interface{}, sport Sport) ([]PendingMatch, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.pendingCollection)
+
+	orderSubmission := bson.D{{"submitted_at", -1}}
+	sorting := options.Find().SetSort(orderSubmission)
+
+	results, err := collection.Find(ctx, filter, sorting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve pending matches: %w", err)
+	}
+
+	pendingMatches := []PendingMatch{}
+
+	for results.Next(ctx) {
+		pending := PendingMatch{}
+		if err := results.Decode(&pending); err != nil {
+			return nil, fmt.Errorf("failed to retrieve pending matches: %w", err)
+		}
+		pendingMatches = append(pendingMatches, pending)
+	}
+
+	return pendingMatches, nil
+}
+
+// claim the pending match, record it and only then remove it from the pending ones; if it was claimed or removed
+// meanwhile (e.g. confirmed twice) nothing is recorded, if it can't be recorded it stays pending and its event
+// is reopened. The date was checked on submission, so the date window doesn't apply
+func (s *MongoSportStore) recordPendingMatch(ctx context.Context, pending *PendingMatch, sport Sport) error {
+	if err := s.claimPendingMatch(ctx, pending, sport); err != nil {
+		return err
+	}
+
+	// the result of an event reopened meanwhile is recorded only if no other result was submitted since
+	if pending.EventID != "" {
+		err := s.setEventMatch(ctx, pending.EventID, pending.ID, sport)
+		if errors.Is(err, ErrEventClosed) {
+			if err := s.deletePendingMatch(ctx, pending, sport); err != nil {
+				return err
+			}
+			return ErrEventClosed
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	m := pending.Match
+	m.GuestRatings = pending.GuestRatings
+
+	// the match keeps the pending id, so a match already recorded by an interrupted confirmation is not recorded twice
+	err := s.addMatch(ctx, &m, noMatchMaxAge, sport)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		if releaseErr := s.releasePendingMatch(ctx, pending, sport); releaseErr != nil {
+			return fmt.Errorf("%w; failed to restore pending match: %v", err, releaseErr)
+		}
+		if reopenErr := s.reopenEvent(ctx, pending.ID, sport); reopenErr != nil {
+			return fmt.Errorf("%w; %v", err, reopenErr)
+		}
+		return err
+	}
+
+	return s.deletePendingMatch(ctx, pending, sport)
+}
+
+// mark the pending match as being recorded, unless another claim is in progress
+func (s *MongoSportStore) claimPendingMatch(ctx context.Context, pending *PendingMatch, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.pendingCollection)
+
+	objectID, err := primitive.ObjectIDFromHex(pending.ID)
+	if err != nil {
+		return ErrNoMatchFound
+	}
+
+	now := time.Now()
+	filter := bson.M{
+		"_id": objectID,
+		"$or": []bson.M{
+			{"confirming_at": bson.M{"$exists": false}},
+			{"confirming_at": bson.M{"$lte": now.Add(-pendingClaimTimeout)}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"confirming_at": now}}
+
+	err = collection.FindOneAndUpdate(ctx, filter, update).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNoMatchFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to claim pending match: %w", err)
+	}
+
+	return nil
+}
+
+// give the pending match back to confirmation
+func (s *MongoSportStore) releasePendingMatch(ctx context.Context, pending *PendingMatch, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.pendingCollection)
+
+	objectID, err := primitive.ObjectIDFromHex(pending.ID)
+	if err != nil {
+		return ErrNoMatchFound
+	}
+
+	update := bson.M{"$unset": bson.M{"confirming_at": ""}}
+
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": objectID}, update); err != nil {
+		return fmt.Errorf("failed to release pending match: %w", err)
+	}
+
+	return nil
+}
+
+func (s *MongoSportStore) deletePendingMatch(ctx context.Context, pending *PendingMatch, sport Sport) error {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.pendingCollection)
+
+	objectID, err := primitive.ObjectIDFromHex(pending.ID)
+	if err != nil {
+		return ErrNoMatchFound
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return fmt.Errorf("failed to delete pending match: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNoMatchFound
+	}
+
+	return nil
+}
+
+// --------------------- FUNCTIONS
+
+// registered players of the team the submitter didn't play for; if the submitter didn't play,
+// any registered player of the match can confirm it
+func confirmers(m *Match, submittedBy string) []string {
+	candidates := append(append([]string{}, m.TeamA...), m.TeamB...)
+	if containsString(m.TeamA, submittedBy) {
+		candidates = m.TeamB
+	} else if containsString(m.TeamB, submittedBy) {
+		candidates = m.TeamA
+	}
+
+	names := []string{}
+	for _, name := range candidates {
+		if !IsGuest(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// number of favourite partners shown for each sport in the profile
+const favouritePartnersCount = 3
+
+// Profile aggregates the stats of a player over all the enabled sports
+type Profile struct {
+	Name     string          `json:"name"`
+	Sports   []SportProfile  `json:"sports"`
+	Activity ActivitySummary `json:"activity"`
+}
+
+type SportProfile struct {
+	Sport             Sport           `json:"sport"`
+	Rating            float64         `json:"rating"`
+	Rank              int             `json:"rank"`
+	RankedPlayers     int             `json:"ranked_players"`
+	MatchCount        int             `json:"match_count"`
+	WinCount          int             `json:"win_count"`
+	WinRate           float64         `json:"win_rate"`
+	FavouritePartners []MateStats     `json:"favourite_partners"`
+	Badges            []UnlockedBadge `json:"badges"`
+	LastMatch         *time.Time      `json:"last_match,omitempty"`
+	// career totals of the individual stats of the sport
+	Stats []CareerStat `json:"stats,omitempty"`
+}
+
+type ActivitySummary struct {
+	TotalMatches    int        `json:"total_matches"`
+	TotalWins       int        `json:"total_wins"`
+	WinRate         float64    `json:"win_rate"`
+	SportsPlayed    int        `json:"sports_played"`
+	MostPlayedSport Sport      `json:"most_played_sport,omitempty"`
+	FirstMatch      *time.Time `json:"first_match,omitempty"`
+	LastMatch       *time.Time `json:"last_match,omitempty"`
+}
+
+func (s *MongoSportStore) GetProfile(ctx context.Context, playerName string) (*Profile, error) {
+	profile := &Profile{
+		Name:   playerName,
+		Sports: []SportProfile{},
+	}
+
+	// visit sports always in the same order
+	sports := make([]Sport, 0, len(s.sportDBs))
+	for sport := range s.sportDBs {
+		sports = append(sports, sport)
+	}
+	sort.Slice(sports, func(i, j int) bool {
+		return sports[i] < sports[j]
+	})
+
+	for _, sport := range sports {
+		player, err := s.findPlayer(ctx, playerName, sport)
+		if err != nil {
+			// player not registered for this sport
+			continue
+		}
+
+		sportProfile := SportProfile{
+			Sport:             sport,
+			Rating:            player.LastElo,
+			MatchCount:        player.MatchCount,
+			WinCount:          player.WinCount,
+			WinRate:           winRate(*player),
+			FavouritePartners: []MateStats{},
+			Badges:            player.Badges,
+		}
+		if sportProfile.Badges == nil {
+			sportProfile.Badges = []UnlockedBadge{}
+		}
+
+		rankedPlayers, err := s.findRankedPlayers(ctx, sport)
+		if err != nil {
+			return nil, err
+		}
+
+		ranking := computeRanking(rankedPlayers, nil, RankingQuery{By: RankByElo})
+		sportProfile.RankedPlayers = len(ranking)
+		for _, entry := range ranking {
+			if entry.Name == playerName {
+				sportProfile.Rank = entry.Position
+			}
+		}
+
+		filter := bson.M{"$or": []bson.M{{"team_a": playerName}, {"team_b": playerName}}}
+
+		matches, err := s.findMatches(ctx, filter, sport)
+		if err != nil {
+			return nil, err
+		}
+
+		partners := sortAndFilterMates(computeMatesMatrix(matches, playerName), MatesQuery{
+			Relation: RelationWith,
+			SortBy:   SortByGames,
+			MinGames: 1,
+		})
+		if len(partners) > favouritePartnersCount {
+			partners = partners[:favouritePartnersCount]
+		}
+		sportProfile.FavouritePartners = partners
+		sportProfile.Stats = computeCareerStats(matches, playerName, SportMatchRules[sport].Stats)
+
+		// matches are ordered by descending date
+		if len(matches) > 0 {
+			lastMatch := matches[0].Date
+			firstMatch := matches[len(matches)-1].Date
+
+			sportProfile.LastMatch = &lastMatch
+			addToActivity(&profile.Activity, firstMatch, lastMatch)
+		}
+
+		profile.Sports = append(profile.Sports, sportProfile)
+	}
+
+	if len(profile.Sports) == 0 {
+		return nil, ErrNoPlayerFound
+	}
+
+	mostPlayedMatches := 0
+	for _, sportProfile := range profile.Sports {
+		profile.Activity.TotalMatches += sportProfile.MatchCount
+		profile.Activity.TotalWins += sportProfile.WinCount
+
+		if sportProfile.MatchCount > 0 {
+			profile.Activity.SportsPlayed++
+		}
+		if sportProfile.MatchCount > mostPlayedMatches {
+			mostPlayedMatches = sportProfile.MatchCount
+			profile.Activity.MostPlayedSport = sportProfile.Sport
+		}
+	}
+	if profile.Activity.TotalMatches > 0 {
+		profile.Activity.WinRate = float64(profile.Activity.TotalWins) / float64(profile.Activity.TotalMatches)
+	}
+
+	return profile, nil
+}
+
+// --------------------- FUNCTIONS
+
+// widen the activity period with the first and last match of a sport
+func addToActivity(activity *ActivitySummary, firstMatch time.Time, lastMatch time.Time) {
+	if activity.FirstMatch == nil || firstMatch.Before(*activity.FirstMatch) {
+		activity.FirstMatch = &firstMatch
+	}
+	if activity.LastMatch == nil || lastMatch.After(*activity.LastMatch) {
+		activity.LastMatch = &lastMatch
+	}
+}
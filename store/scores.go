@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// matches decided by at most closeGameMargin points are close games
+const closeGameMargin = 2
+
+// matches decided by at least this margin are blowouts
+var BlowoutMargin = map[Sport]int{
+	Beachvolley: 10,
+	Basket:      20,
+	Pool:        3,
+}
+
+type ScoreStats struct {
+	Matches          int     `json:"matches"`
+	PointsFor        int     `json:"points_for"`
+	PointsAgainst    int     `json:"points_against"`
+	AvgPointsFor     float64 `json:"avg_points_for"`
+	AvgPointsAgainst float64 `json:"avg_points_against"`
+	AvgMargin        float64 `json:"avg_margin"`
+	Draws            int     `json:"draws"`
+	CloseWins        int     `json:"close_wins"`
+	CloseLosses      int     `json:"close_losses"`
+	BlowoutWins      int     `json:"blowout_wins"`
+	BlowoutLosses    int     `json:"blowout_losses"`
+
+	// only matches played in sets count
+	SetsWon        int `json:"sets_won"`
+	SetsLost       int `json:"sets_lost"`
+	TiebreakWins   int `json:"tiebreak_wins"`
+	TiebreakLosses int `json:"tiebreak_losses"`
+}
+
+// PairingScoreStats are the score stats of a player when teaming up with a mate
+type PairingScoreStats struct {
+	Mate string `json:"mate"`
+	ScoreStats
+}
+
+func (s *MongoSportStore) GetScoreStats(ctx context.Context, playerName string, sport Sport) (*ScoreStats, []PairingScoreStats, error) {
+	if _, err := s.findPlayer(ctx, playerName, sport); err != nil {
+		return nil, nil, err
+	}
+
+	// get all matches for given player
+	filter := bson.M{"$or": []bson.M{{"team_a": playerName}, {"team_b": playerName}}}
+
+	matches, err := s.findMatches(ctx, filter, sport)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stats, pairings := computeScoreStats(matches, playerName, BlowoutMargin[sport])
+
+	return stats, pairings, nil
+}
+
+// --------------------- FUNCTIONS
+
+// compute score stats of a player, overall and for every mate they teamed up with (most played pairing first)
+func computeScoreStats(matches []Match, playerName string, blowoutMargin int) (*ScoreStats, []PairingScoreStats) {
+	stats := &ScoreStats{}
+	pairingsMap := make(map[string]*ScoreStats)
+
+	for _, m := range matches {
+		mates, _, pointsFor, pointsAgainst, isWinner := matchSide(m, playerName)
+
+		addToScoreStats(stats, pointsFor, pointsAgainst, isWinner, m.IsDraw(), blowoutMargin)
+		addToSetStats(stats, m, playerName)
+
+		for _, mate := range mates {
+			if mate == playerName {
+				continue
+			}
+			if _, ok := pairingsMap[mate]; !ok {
+				pairingsMap[mate] = &ScoreStats{}
+			}
+			addToScoreStats(pairingsMap[mate], pointsFor, pointsAgainst, isWinner, m.IsDraw(), blowoutMargin)
+			addToSetStats(pairingsMap[mate], m, playerName)
+		}
+	}
+
+	pairings := make([]PairingScoreStats, 0, len(pairingsMap))
+	for mate, mateStats := range pairingsMap {
+		pairings = append(pairings, PairingScoreStats{
+			Mate:       mate,
+			ScoreStats: *mateStats,
+		})
+	}
+	sort.Slice(pairings, func(i, j int) bool {
+		if pairings[i].Matches != pairings[j].Matches {
+			return pairings[i].Matches > pairings[j].Matches
+		}
+		return pairings[i].Mate < pairings[j].Mate
+	})
+
+	return stats, pairings
+}
+
+func addToScoreStats(stats *ScoreStats, pointsFor int, pointsAgainst int, isWinner bool, isDraw bool, blowoutMargin int) {
+	stats.Matches++
+	stats.PointsFor += pointsFor
+	stats.PointsAgainst += pointsAgainst
+
+	stats.AvgPointsFor = float64(stats.PointsFor) / float64(stats.Matches)
+	stats.AvgPointsAgainst = float64(stats.PointsAgainst) / float64(stats.Matches)
+	stats.AvgMargin = float64(stats.PointsFor-stats.PointsAgainst) / float64(stats.Matches)
+
+	// a draw is neither close nor a blowout
+	if isDraw {
+		stats.Draws++
+		return
+	}
+
+	margin := pointsFor - pointsAgainst
+	if margin < 0 {
+		margin = -margin
+	}
+
+	if margin <= closeGameMargin {
+		if isWinner {
+			stats.CloseWins++
+		} else {
+			stats.CloseLosses++
+		}
+	}
+	if blowoutMargin > 0 && margin >= blowoutMargin {
+		if isWinner {
+			stats.BlowoutWins++
+		} else {
+			stats.BlowoutLosses++
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// default number of players per team for each sport
+var SportTeamSize = map[Sport]int{
+	Beachvolley: 2,
+	Basket:      5,
+	Pool:        1,
+}
+
+// penalty added to the teams value difference for every time two partners already played together in the session
+const partnerRepeatPenalty = 50.0
+
+type SessionSchedule struct {
+	Rounds   []SessionRound  `json:"rounds"`
+	Fairness SessionFairness `json:"fairness"`
+}
+
+type SessionRound struct {
+	Round   int          `json:"round"`
+	Courts  []CourtMatch `json:"courts"`
+	SitOuts []string     `json:"sit_outs"`
+}
+
+type CourtMatch struct {
+	Court               int      `json:"court"`
+	TeamA               []string `json:"team_a"`
+	TeamB               []string `json:"team_b"`
+	TeamValueDifference float64  `json:"team_value_difference"`
+}
+
+type SessionFairness struct {
+	SitOutCounts           map[string]int `json:"sit_out_counts"`
+	MinSitOuts             int            `json:"min_sit_outs"`
+	MaxSitOuts             int            `json:"max_sit_outs"`
+	DistinctPartners       map[string]int `json:"distinct_partners"`
+	RepeatedPartnerships   int            `json:"repeated_partnerships"`
+	MaxPartnerRepeats      int            `json:"max_partner_repeats"`
+	AvgTeamValueDifference float64        `json:"avg_team_value_difference"`
+	MaxTeamValueDifference float64        `json:"max_team_value_difference"`
+}
+
+func (s *MongoSportStore) GenerateSessionSchedule(ctx context.Context, players []Player, courts int, rounds int, teamSize int, seed int64, sport Sport) (*SessionSchedule, error) {
+	// retrieve players stats
+	playersStats, err := s.getPlayersRtValues(ctx, players, sport)
+	if err != nil {
+		return nil, err
+	}
+
+	if teamSize <= 0 {
+		teamSize = SportTeamSize[sport]
+	}
+	if len(playersStats) < 2*teamSize {
+		return nil, ErrNotEnoughPlayers
+	}
+
+	return scheduleSession(playersStats, courts, rounds, teamSize, rand.New(rand.NewSource(seed))), nil
+}
+
+// --------------------- FUNCTIONS
+
+// generate the rounds of a session such that, in each round:
+// sit-outs go to the players who sat out less so far,
+// the remaining players are spread over the courts and every court gets balanced teams
+// where partners that already played together are penalized
+func scheduleSession(players map[string]float64, courts int, rounds int, teamSize int, r *rand.Rand) *SessionSchedule {
+	names := make([]string, 0, len(players))
+	for name := range players {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	playersPerCourt := 2 * teamSize
+	activeCourts := len(names) / playersPerCourt
+	if courts < activeCourts {
+		activeCourts = courts
+	}
+	activeCount := activeCourts * playersPerCourt
+
+	sitOutCounts := make(map[string]int)
+	partners := make(map[string]map[string]int)
+	for _, name := range names {
+		sitOutCounts[name] = 0
+		partners[name] = make(map[string]int)
+	}
+
+	schedule := &SessionSchedule{}
+
+	for round := 1; round <= rounds; round++ {
+		// shuffle first, then a stable sort on sit-outs: players who sat out more play first, ties are broken randomly
+		order := make([]string, len(names))
+		copy(order, names)
+		r.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+		sort.SliceStable(order, func(i, j int) bool {
+			return sitOutCounts[order[i]] > sitOutCounts[order[j]]
+		})
+
+		active := order[:activeCount]
+		sitOuts := append([]string{}, order[activeCount:]...)
+		sort.Strings(sitOuts)
+		for _, name := range sitOuts {
+			sitOutCounts[name]++
+		}
+
+		sessionRound := SessionRound{
+			Round:   round,
+			SitOuts: sitOuts,
+		}
+
+		for c := 0; c < activeCourts; c++ {
+			courtPlayers := make(map[string]float64)
+			for _, name := range active[c*playersPerCourt : (c+1)*playersPerCourt] {
+				courtPlayers[name] = players[name]
+			}
+
+			team1, team2, rtValueDiff := balanceTeamsWithRotation(courtPlayers, partners)
+
+			registerPartners(partners, team1)
+			registerPartners(partners, team2)
+
+			sessionRound.Courts = append(sessionRound.Courts, CourtMatch{
+				Court:               c + 1,
+				TeamA:               team1,
+				TeamB:               team2,
+				TeamValueDifference: rtValueDiff,
+			})
+		}
+
+		schedule.Rounds = append(schedule.Rounds, sessionRound)
+	}
+
+	schedule.Fairness = computeSessionFairness(schedule, sitOutCounts, partners)
+
+	return schedule
+}
+
+// make two teams of equal size from a snake draft on rtValues, then swap players between teams
+// (as balanceTeams does) as long as value difference + penalty for repeated partners decreases
+func balanceTeamsWithRotation(players map[string]float64, partners map[string]map[string]int) ([]string, []string, float64) {
+	team1, team2 := snakeDraft(players)
+
+	cost := rotationCost(team1, team2, players, partners)
+
+	for improved := true; improved; {
+		improved = false
+		for i := range team1 {
+			for j := range team2 {
+				team1[i], team2[j] = team2[j], team1[i]
+
+				newCost := rotationCost(team1, team2, players, partners)
+				if newCost < cost {
+					cost = newCost
+					improved = true
+				} else {
+					team1[i], team2[j] = team2[j], team1[i]
+				}
+			}
+		}
+	}
+
+	return team1, team2, math.Abs(teamValue(team1, players) - teamValue(team2, players))
+}
+
+// sort players from higher to lower rtValue and pick them in A-B-B-A order
+func snakeDraft(players map[string]float64) ([]string, []string) {
+	keys := make([]string, 0, len(players))
+	for key := range players {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return players[keys[i]] > players[keys[j]]
+	})
+
+	var team1 []string
+	var team2 []string
+
+	for i, key := range keys {
+		if i%4 == 0 || i%4 == 3 {
+			team1 = append(team1, key)
+		} else {
+			team2 = append(team2, key)
+		}
+	}
+
+	return team1, team2
+}
+
+func rotationCost(team1 []string, team2 []string, players map[string]float64, partners map[string]map[string]int) float64 {
+	repeats := countPartnerRepeats(team1, partners) + countPartnerRepeats(team2, partners)
+
+	return math.Abs(teamValue(team1, players)-teamValue(team2, players)) + partnerRepeatPenalty*float64(repeats)
+}
+
+func teamValue(team []string, players map[string]float64) float64 {
+	var value float64
+	for _, name := range team {
+		value += players[name]
+	}
+	return value
+}
+
+// count how many times the pairs of a team already played together
+func countPartnerRepeats(team []string, partners map[string]map[string]int) int {
+	repeats := 0
+	for i := 0; i < len(team); i++ {
+		for j := i + 1; j < len(team); j++ {
+			repeats += partners[team[i]][team[j]]
+		}
+	}
+	return repeats
+}
+
+func registerPartners(partners map[string]map[string]int, team []string) {
+	for i := 0; i < len(team); i++ {
+		for j := i + 1; j < len(team); j++ {
+			partners[team[i]][team[j]]++
+			partners[team[j]][team[i]]++
+		}
+	}
+}
+
+func computeSessionFairness(schedule *SessionSchedule, sitOutCounts map[string]int, partners map[string]map[string]int) SessionFairness {
+	fairness := SessionFairness{
+		SitOutCounts:     sitOutCounts,
+		MinSitOuts:       math.MaxInt,
+		DistinctPartners: make(map[string]int),
+	}
+
+	for name, count := range sitOutCounts {
+		if count < fairness.MinSitOuts {
+			fairness.MinSitOuts = count
+		}
+		if count > fairness.MaxSitOuts {
+			fairness.MaxSitOuts = count
+		}
+
+		fairness.DistinctPartners[name] = len(partners[name])
+
+		for mate, together := range partners[name] {
+			// count every pair once
+			if name < mate && together > 1 {
+				fairness.RepeatedPartnerships++
+				if together-1 > fairness.MaxPartnerRepeats {
+					fairness.MaxPartnerRepeats = together - 1
+				}
+			}
+		}
+	}
+
+	var courtMatches int
+	for _, round := range schedule.Rounds {
+		for _, court := range round.Courts {
+			courtMatches++
+			fairness.AvgTeamValueDifference += court.TeamValueDifference
+			if court.TeamValueDifference > fairness.MaxTeamValueDifference {
+				fairness.MaxTeamValueDifference = court.TeamValueDifference
+			}
+		}
+	}
+	if courtMatches > 0 {
+		fairness.AvgTeamValueDifference = fairness.AvgTeamValueDifference / float64(courtMatches)
+	}
+
+	return fairness
+}
@@ -0,0 +1,120 @@
+package store
+
+import "fmt"
+
+type SetScore struct {
+	ScoreA int `json:"score_a" bson:"score_a"`
+	ScoreB int `json:"score_b" bson:"score_b"`
+}
+
+// total points scored by each team: the sum of the set scores if the match was played in sets
+func (m Match) Points() (int, int) {
+	if len(m.Sets) == 0 {
+		return m.ScoreA, m.ScoreB
+	}
+
+	var pointsA, pointsB int
+	for _, set := range m.Sets {
+		pointsA += set.ScoreA
+		pointsB += set.ScoreB
+	}
+	return pointsA, pointsB
+}
+
+// a match ends in a draw when both teams have the same final score
+func (m Match) IsDraw() bool {
+	return m.ScoreA == m.ScoreB
+}
+
+// --------------------- FUNCTIONS
+
+func setsWon(sets []SetScore) (int, int) {
+	var setsA, setsB int
+	for _, set := range sets {
+		if set.ScoreA > set.ScoreB {
+			setsA++
+		} else if set.ScoreB > set.ScoreA {
+			setsB++
+		}
+	}
+	return setsA, setsB
+}
+
+// check every set against the rules and that the match ends as soon as a team wins the needed sets
+func checkSets(sets []SetScore, rules MatchRules) []FieldError {
+	if rules.SetsToWin == 0 {
+		return []FieldError{{Field: "sets", Message: "sets are not supported by this sport"}}
+	}
+
+	var fields []FieldError
+	var setsA, setsB int
+
+	for i, set := range sets {
+		field := fmt.Sprintf("sets[%d]", i)
+
+		if setsA == rules.SetsToWin || setsB == rules.SetsToWin {
+			fields = append(fields, FieldError{Field: field, Message: "match was already decided"})
+			continue
+		}
+		if set.ScoreA < 0 || set.ScoreB < 0 {
+			fields = append(fields, FieldError{Field: field, Message: "score can't be negative"})
+			continue
+		}
+
+		// the deciding set is played to fewer points
+		target := rules.TargetPoints
+		if i == 2*rules.SetsToWin-2 && rules.TiebreakPoints > 0 {
+			target = rules.TiebreakPoints
+		}
+		fields = append(fields, checkScore(set.ScoreA, set.ScoreB, target, rules.WinBy, field)...)
+
+		if set.ScoreA > set.ScoreB {
+			setsA++
+		} else if set.ScoreB > set.ScoreA {
+			setsB++
+		}
+	}
+
+	if setsA < rules.SetsToWin && setsB < rules.SetsToWin {
+		fields = append(fields, FieldError{Field: "sets", Message: fmt.Sprintf("a team must win %d sets", rules.SetsToWin)})
+	}
+
+	return fields
+}
+
+// add the sets of a match to the set stats of a player; the deciding set, played when teams
+// have won the same number of sets, is the tiebreak
+func addToSetStats(stats *ScoreStats, m Match, playerName string) {
+	if len(m.Sets) == 0 {
+		return
+	}
+
+	inTeamA := containsString(m.TeamA, playerName)
+
+	var setsA, setsB int
+	for i, set := range m.Sets {
+		setFor, setAgainst := set.ScoreA, set.ScoreB
+		if !inTeamA {
+			setFor, setAgainst = set.ScoreB, set.ScoreA
+		}
+
+		isTiebreak := i > 0 && i == len(m.Sets)-1 && setsA == setsB
+		if setFor > setAgainst {
+			stats.SetsWon++
+			if isTiebreak {
+				stats.TiebreakWins++
+			}
+		} else {
+			stats.SetsLost++
+			if isTiebreak {
+				stats.TiebreakLosses++
+			}
+		}
+
+		if set.ScoreA > set.ScoreB {
+			setsA++
+		} else {
+			setsB++
+		}
+	}
+}
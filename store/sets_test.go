@@ -0,0 +1,72 @@
+package store
+
+import "testing"
+
+func TestCheckSets(t *testing.T) {
+	rules := SportMatchRules[Beachvolley]
+
+	tests := []struct {
+		name   string
+		sets   []SetScore
+		fields []string
+	}{
+		{"straight sets", []SetScore{{21, 15}, {21, 19}}, nil},
+		{"win by 2 past the target", []SetScore{{22, 20}, {21, 18}}, nil},
+		{"long deuce", []SetScore{{30, 28}, {21, 10}}, nil},
+		{"one point margin at the target", []SetScore{{21, 20}, {21, 15}}, []string{"sets[0]"}},
+		{"target not reached", []SetScore{{20, 18}, {21, 15}}, []string{"sets[0]"}},
+		{"past the target with a margin of 3", []SetScore{{23, 20}, {21, 15}}, []string{"sets[0]"}},
+		{"deciding set to 15", []SetScore{{21, 15}, {18, 21}, {15, 13}}, nil},
+		{"deciding set to 15 won by 1", []SetScore{{21, 15}, {18, 21}, {15, 14}}, []string{"sets[2]"}},
+		{"deciding set past 15", []SetScore{{21, 15}, {18, 21}, {17, 15}}, nil},
+		{"first set to 15", []SetScore{{15, 13}, {21, 15}}, []string{"sets[0]"}},
+		{"set after the match is decided", []SetScore{{21, 15}, {21, 15}, {15, 10}}, []string{"sets[2]"}},
+		{"match not decided", []SetScore{{21, 15}, {15, 21}}, []string{"sets"}},
+		{"negative score", []SetScore{{-1, 21}, {21, 15}, {15, 10}}, []string{"sets[0]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFields(t, checkSets(tt.sets, rules), tt.fields)
+		})
+	}
+}
+
+func TestCheckSetsNotSupported(t *testing.T) {
+	assertFields(t, checkSets([]SetScore{{11, 5}}, SportMatchRules[Pool]), []string{"sets"})
+}
+
+func TestSetsWon(t *testing.T) {
+	tests := []struct {
+		name         string
+		sets         []SetScore
+		setsA, setsB int
+	}{
+		{"no sets", nil, 0, 0},
+		{"straight sets", []SetScore{{21, 15}, {21, 19}}, 2, 0},
+		{"three sets", []SetScore{{21, 15}, {18, 21}, {13, 15}}, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setsA, setsB := setsWon(tt.sets)
+			if setsA != tt.setsA || setsB != tt.setsB {
+				t.Errorf("setsWon() = %d-%d, want %d-%d", setsA, setsB, tt.setsA, tt.setsB)
+			}
+		})
+	}
+}
+
+// check that the errors are exactly on the given fields, in order
+func assertFields(t *testing.T, got []FieldError, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got errors %v, want errors on %v", got, want)
+	}
+	for i := range got {
+		if got[i].Field != want[i] {
+			t.Errorf("error %d on %q (%s), want on %q", i, got[i].Field, got[i].Message, want[i])
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+)
+
+// StatDefinition is an individual stat recorded for the players of a match
+type StatDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// the stats of the players of a team add up to the team score
+	TeamScore bool `json:"team_score"`
+	// a player can't get more than the score of their team
+	UpToTeamScore bool `json:"up_to_team_score"`
+}
+
+// StatLine is the value of every stat of a player in a match, by stat name
+type StatLine map[string]int
+
+var basketStats = []StatDefinition{
+	{Name: "points", Description: "points scored", TeamScore: true},
+}
+
+var poolStats = []StatDefinition{
+	{Name: "balls_potted", Description: "balls potted"},
+	{Name: "break_and_runs", Description: "racks won from the break without the opponent coming to the table", UpToTeamScore: true},
+}
+
+// CareerStat is the total of a stat over the matches a player has a stat line in
+type CareerStat struct {
+	Name     string  `json:"name"`
+	Matches  int     `json:"matches"`
+	Total    int     `json:"total"`
+	PerMatch float64 `json:"per_match"`
+	Best     int     `json:"best"`
+}
+
+// the stat of the sport with the given name
+func FindStat(sport Sport, name string) (StatDefinition, bool) {
+	return findStatDefinition(SportMatchRules[sport].Stats, name)
+}
+
+// --------------------- FUNCTIONS
+
+// check the stat lines of a match against the stats of the sport: players must be in the match, stats known
+// and not negative; team totals are checked only when every player of the team has the stat
+func checkStatLines(m *Match, stats []StatDefinition) []FieldError {
+	if len(m.StatLines) == 0 {
+		return nil
+	}
+	if len(stats) == 0 {
+		return []FieldError{{Field: "stat_lines", Message: "stat lines are not supported by this sport"}}
+	}
+
+	var fields []FieldError
+
+	// visit players and stats always in the same order
+	names := make([]string, 0, len(m.StatLines))
+	for name := range m.StatLines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := "stat_lines." + name
+
+		teamScore := m.ScoreA
+		if containsString(m.TeamB, name) {
+			teamScore = m.ScoreB
+		} else if !containsString(m.TeamA, name) {
+			fields = append(fields, FieldError{Field: field, Message: fmt.Sprintf("player %s did not play the match", name)})
+			continue
+		}
+
+		statNames := make([]string, 0, len(m.StatLines[name]))
+		for stat := range m.StatLines[name] {
+			statNames = append(statNames, stat)
+		}
+		sort.Strings(statNames)
+
+		for _, stat := range statNames {
+			value := m.StatLines[name][stat]
+			definition, ok := findStatDefinition(stats, stat)
+			if !ok {
+				fields = append(fields, FieldError{Field: field, Message: fmt.Sprintf("unknown stat %s", stat)})
+				continue
+			}
+			if value < 0 {
+				fields = append(fields, FieldError{Field: field + "." + stat, Message: "stat can't be negative"})
+				continue
+			}
+			if definition.UpToTeamScore && value > teamScore {
+				fields = append(fields, FieldError{Field: field + "." + stat, Message: fmt.Sprintf("stat can't be more than the team score %d", teamScore)})
+			}
+		}
+	}
+
+	for _, definition := range stats {
+		if !definition.TeamScore {
+			continue
+		}
+		for _, team := range []struct {
+			field   string
+			players []string
+			score   int
+		}{{"team_a", m.TeamA, m.ScoreA}, {"team_b", m.TeamB, m.ScoreB}} {
+			total, complete := 0, true
+			for _, name := range team.players {
+				value, ok := m.StatLines[name][definition.Name]
+				if !ok {
+					complete = false
+					break
+				}
+				total += value
+			}
+			if complete && total != team.score {
+				fields = append(fields, FieldError{
+					Field:   "stat_lines",
+					Message: fmt.Sprintf("%s of %s add up to %d instead of the team score %d", definition.Name, team.field, total, team.score),
+				})
+			}
+		}
+	}
+
+	return fields
+}
+
+func findStatDefinition(stats []StatDefinition, name string) (StatDefinition, bool) {
+	for _, stat := range stats {
+		if stat.Name == name {
+			return stat, true
+		}
+	}
+	return StatDefinition{}, false
+}
+
+// compute the career totals of a player for every stat of the sport
+func computeCareerStats(matches []Match, playerName string, stats []StatDefinition) []CareerStat {
+	careerStats := make([]CareerStat, 0, len(stats))
+
+	for _, definition := range stats {
+		careerStat := CareerStat{Name: definition.Name}
+
+		for _, m := range matches {
+			value, ok := m.StatLines[playerName][definition.Name]
+			if !ok {
+				continue
+			}
+			if careerStat.Matches == 0 || value > careerStat.Best {
+				careerStat.Best = value
+			}
+			careerStat.Matches++
+			careerStat.Total += value
+		}
+		if careerStat.Matches > 0 {
+			careerStat.PerMatch = float64(careerStat.Total) / float64(careerStat.Matches)
+		}
+
+		careerStats = append(careerStats, careerStat)
+	}
+
+	return careerStats
+}
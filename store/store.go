@@ -16,18 +16,56 @@ type UserStore interface {
 
 type SportStore interface {
 	AddMatch(ctx context.Context, match *Match, sport Sport) error
-	GetMatches(ctx context.Context, playerName string, sport Sport) ([]byte, error)
-	DeleteMatch(ctx context.Context, date time.Time, sport Sport) error
+	GetMatches(ctx context.Context, playerName string, location string, sport Sport) ([]byte, error)
+	DeleteMatch(ctx context.Context, date time.Time, deletedBy string, sport Sport) error
+	EditMatch(ctx context.Context, id string, match *Match, editedBy string, sport Sport) error
+	ImportMatches(ctx context.Context, rows []ImportRow, dryRun bool, createPlayers bool, sport Sport) (*ImportReport, error)
+	ExportMatches(ctx context.Context, sport Sport, export func(m Match) error) error
+	GetUpsets(ctx context.Context, limit int, sport Sport) ([]Match, error)
+
+	SubmitMatch(ctx context.Context, match *Match, submittedBy string, sport Sport) (string, error)
+	GetPendingMatches(ctx context.Context, playerName string, sport Sport) ([]PendingMatch, error)
+	ConfirmMatch(ctx context.Context, id string, playerName string, sport Sport) error
+	RejectMatch(ctx context.Context, id string, playerName string, sport Sport) error
+	ConfirmExpiredMatches(ctx context.Context, sport Sport) error
+
+	AddEvent(ctx context.Context, event *Event, sport Sport) (string, error)
+	GetEvents(ctx context.Context, sport Sport) ([]Event, error)
+	GetEvent(ctx context.Context, id string, sport Sport) (*Event, error)
+	RSVPEvent(ctx context.Context, id string, playerName string, status RSVPStatus, sport Sport) error
+	BalanceEvent(ctx context.Context, id string, opts BalanceOptions, sport Sport) (*BalancedTeams, error)
+	RecordEventResult(ctx context.Context, id string, match *Match, recordedBy string, sport Sport) (string, error)
 
 	AddUserToSportDBs(ctx context.Context, user *User) error
 	AddExistingUserToNewSportDBs(ctx context.Context, user *User) error
 
 	AddPlayer(ctx context.Context, player *Player, sport Sport) error
 	GetPlayers(ctx context.Context, sport Sport) ([]byte, error)
+	ExportPlayers(ctx context.Context, sport Sport, export func(p Player) error) error
 	GetPlayer(ctx context.Context, playerName string, sport Sport) ([]byte, error)
-	GetRanking(ctx context.Context, sport Sport) ([]byte, error)
-	GenerateBalancedTeams(ctx context.Context, players []Player, sport Sport) ([]string, []string, float64, int, error)
+	UpdatePlayerAttributes(ctx context.Context, playerName string, attributes map[string]string, sport Sport) error
+	GetRanking(ctx context.Context, query RankingQuery, sport Sport) ([]byte, error)
+	GetStreakRanking(ctx context.Context, sport Sport) ([]byte, error)
+	TakeRankingSnapshot(ctx context.Context, sport Sport) error
+
+	RecomputeAchievements(ctx context.Context, sport Sport) error
+	RecomputeStreaks(ctx context.Context, sport Sport) error
+	GenerateBalancedTeams(ctx context.Context, players []Player, opts BalanceOptions, sport Sport) (*BalancedTeams, error)
 	GetMates(ctx context.Context, playerName string, sport Sport) (*Mate, *Mate, error)
+	GetPlayerValue(ctx context.Context, playerName string, sport Sport) (*PlayerValue, error)
+	GetLocationStats(ctx context.Context, playerName string, sport Sport) ([]LocationStats, error)
+	GetGiantKiller(ctx context.Context, playerName string, sport Sport) (*GiantKiller, error)
+	ComparePlayers(ctx context.Context, playersNames []string, sport Sport) (*Comparison, error)
+	GetProfile(ctx context.Context, playerName string) (*Profile, error)
+	GetScoreStats(ctx context.Context, playerName string, sport Sport) (*ScoreStats, []PairingScoreStats, error)
+	GetMatesMatrix(ctx context.Context, playerName string, query MatesQuery, sport Sport) ([]MateStats, error)
+	GetHeadToHead(ctx context.Context, playerName string, otherName string, lastMeetings int, sport Sport) (*HeadToHead, error)
+	ClaimGuest(ctx context.Context, guestName string, playerName string, sport Sport) error
+	GetGuestClaims(ctx context.Context, sport Sport) ([]Player, error)
+	ConfirmGuestClaim(ctx context.Context, guestName string, sport Sport) error
+	RejectGuestClaim(ctx context.Context, guestName string, sport Sport) error
+
+	GenerateSessionSchedule(ctx context.Context, players []Player, courts int, rounds int, teamSize int, seed int64, sport Sport) (*SessionSchedule, error)
 }
 
 type Sport string
@@ -54,6 +92,25 @@ var (
 	ErrNoPlayerFound    = errors.New("no player found")
 	ErrPlayerDuplicated = errors.New("player already registered")
 	ErrNoMatchFound     = errors.New("no match found")
+	ErrNotEnoughPlayers = errors.New("not enough players to fill a court")
+
+	ErrGuestClaimConflict = errors.New("guest played in a match together with the claiming player")
+	ErrGuestClaimPending  = errors.New("guest is already claimed by another player")
+	ErrNoGuestClaim       = errors.New("guest has no pending claim")
+	ErrRulesNotSatisfied  = errors.New("attribute rules can't be satisfied by the given players")
+	ErrNotValidAttribute  = errors.New("attribute name is not valid")
+	ErrSubstituteNotFound = errors.New("substitute is not among the players")
+	ErrNotValidComparison = errors.New("players to compare must be between 2 and 6")
+	ErrSamePlayer         = errors.New("player can't be compared with themselves")
+	ErrNotValidMatch      = errors.New("match is not valid")
+
+	ErrNotAllowedToConfirm = errors.New("player is not allowed to confirm or reject the match")
+	ErrNotAllowedToEdit    = errors.New("player is not allowed to edit or delete the match")
+
+	ErrNoEventFound  = errors.New("no event found")
+	ErrNotValidEvent = errors.New("event is not valid")
+	ErrEventFull     = errors.New("event is full")
+	ErrEventClosed   = errors.New("event result already recorded")
 )
 
 type StoreType int
@@ -89,11 +146,38 @@ func InitializeDB(ctx context.Context, t StoreType) error {
 }
 
 type Match struct {
+	ID     string    `json:"id,omitempty" bson:"_id,omitempty"`
 	TeamA  []string  `json:"team_a" bson:"team_a"`
 	TeamB  []string  `json:"team_b" bson:"team_b"`
 	ScoreA int       `json:"score_a" bson:"score_a"`
 	ScoreB int       `json:"score_b" bson:"score_b"`
 	Date   time.Time `json:"date" bson:"date"`
+
+	// set scores, if the match was played in sets; ScoreA and ScoreB are then the sets won
+	Sets []SetScore `json:"sets,omitempty" bson:"sets,omitempty"`
+
+	// optional details: court or location, free-text notes and duration in minutes
+	Location        string `json:"location,omitempty" bson:"location,omitempty"`
+	Notes           string `json:"notes,omitempty" bson:"notes,omitempty"`
+	DurationMinutes int    `json:"duration_minutes,omitempty" bson:"duration_minutes,omitempty"`
+	// user who recorded the match
+	RecordedBy string `json:"recorded_by,omitempty" bson:"recorded_by,omitempty"`
+
+	// individual stats of the players, by player name, as defined by the sport
+	StatLines map[string]StatLine `json:"stat_lines,omitempty" bson:"stat_lines,omitempty"`
+
+	// rating change of every player due to the match
+	RatingDeltas map[string]float64 `json:"rating_deltas,omitempty" bson:"rating_deltas,omitempty"`
+
+	// probability, before the match, that the winning team would lose; upset if the underdog won
+	Surprise float64 `json:"surprise,omitempty" bson:"surprise,omitempty"`
+	Upset    bool    `json:"upset,omitempty" bson:"upset,omitempty"`
+
+	// previous results of the match, if it was edited
+	History []MatchEdit `json:"history,omitempty" bson:"history,omitempty"`
+
+	// estimated ratings for guests at their first match, not stored
+	GuestRatings map[string]float64 `json:"guest_ratings,omitempty" bson:"-"`
 }
 
 type Player struct {
@@ -101,8 +185,19 @@ type Player struct {
 	Name       string    `json:"name" bson:"name"`
 	MatchCount int       `json:"match_count" bson:"match_count"`
 	WinCount   int       `json:"win_count" bson:"win_count"`
+	DrawCount  int       `json:"draw_count" bson:"draw_count"`
 	Elo        []float64 `json:"elo" bson:"elo"`
 	LastElo    float64   `json:"last_elo" bson:"last_elo"`
+	Streaks    Streaks   `json:"streaks" bson:"streaks"`
+
+	Badges []UnlockedBadge `json:"badges,omitempty" bson:"badges,omitempty"`
+
+	// free per-sport attributes, e.g. gender, position, height class
+	Attributes map[string]string `json:"attributes,omitempty" bson:"attributes,omitempty"`
+
+	// guests only: player who asked to claim the guest history, until an admin confirms or rejects it
+	ClaimedBy string     `json:"claimed_by,omitempty" bson:"claimed_by,omitempty"`
+	ClaimedAt *time.Time `json:"claimed_at,omitempty" bson:"claimed_at,omitempty"`
 }
 
 type User struct {
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Streaks struct {
+	CurrentWin  int `json:"current_win" bson:"current_win"`
+	CurrentLoss int `json:"current_loss" bson:"current_loss"`
+	LongestWin  int `json:"longest_win" bson:"longest_win"`
+	LongestLoss int `json:"longest_loss" bson:"longest_loss"`
+}
+
+func (s *MongoSportStore) GetStreakRanking(ctx context.Context, sport Sport) ([]byte, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.playerCollection)
+
+	// get all players on a win streak, ordered by max(current win streak), max(longest win streak) and alphabetical(name)
+	filter := bson.D{{"streaks.current_win", bson.D{{"$gt", 0}}}}
+	order := bson.D{{"streaks.current_win", -1}, {"streaks.longest_win", -1}, {"name", 1}}
+	sorting := options.Find().SetSort(order)
+
+	results, err := collection.Find(ctx, filter, sorting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve streak ranking of players: %w", err)
+	}
+
+	var players []Player
+
+	for results.Next(ctx) {
+		player := Player{}
+		if err := results.Decode(&player); err != nil {
+			return nil, fmt.Errorf("failed to retrieve player: %w", err)
+		}
+		players = append(players, player)
+	}
+
+	if len(players) == 0 {
+		return nil, ErrNoPlayerFound
+	}
+
+	return json.Marshal(players)
+}
+
+// recompute from the match history the streaks of the given players
+func (s *MongoSportStore) updateStreaks(ctx context.Context, players []string, sport Sport) error {
+	for _, p := range players {
+
+		filter := bson.M{"$or": []bson.M{{"team_a": p}, {"team_b": p}}}
+
+		matches, err := s.findMatches(ctx, filter, sport)
+		if err != nil {
+			return err
+		}
+
+		streaks := computeStreaks(matches, p)
+
+		collection := s.playerCollectionFor(p, sport)
+
+		update := bson.M{"$set": bson.M{"streaks": streaks}}
+		opts := options.Update().SetUpsert(false)
+
+		_, err = collection.UpdateOne(ctx, bson.M{"name": p}, update, opts)
+		if err != nil {
+			return fmt.Errorf("failed to update player streaks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// recompute from the match history the streaks of every player and guest, e.g. to fill the ones
+// of players who did not play since streaks were introduced
+func (s *MongoSportStore) RecomputeStreaks(ctx context.Context, sport Sport) error {
+	players, err := s.findAllPlayers(ctx, sport)
+	if err != nil {
+		return err
+	}
+
+	matches, err := s.findMatches(ctx, bson.M{}, sport)
+	if err != nil {
+		return err
+	}
+
+	// matches of every player, latest first as findMatches returns them
+	playerMatches := make(map[string][]Match)
+	for _, m := range matches {
+		for _, name := range append(append([]string{}, m.TeamA...), m.TeamB...) {
+			playerMatches[name] = append(playerMatches[name], m)
+		}
+	}
+
+	for _, p := range players {
+		collection := s.playerCollectionFor(p.Name, sport)
+
+		update := bson.M{"$set": bson.M{"streaks": computeStreaks(playerMatches[p.Name], p.Name)}}
+		opts := options.Update().SetUpsert(false)
+
+		if _, err := collection.UpdateOne(ctx, bson.M{"name": p.Name}, update, opts); err != nil {
+			return fmt.Errorf("failed to update player streaks: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// --------------------- FUNCTIONS
+
+// compute current and longest win/loss streaks of a player; matches are ordered by descending date
+func computeStreaks(matches []Match, playerName string) Streaks {
+	streaks := Streaks{}
+
+	for i := len(matches) - 1; i >= 0; i-- {
+		_, _, _, _, isWinner := matchSide(matches[i], playerName)
+
+		// a draw breaks both streaks
+		if matches[i].IsDraw() {
+			streaks.CurrentWin = 0
+			streaks.CurrentLoss = 0
+		} else if isWinner {
+			streaks.CurrentWin++
+			streaks.CurrentLoss = 0
+		} else {
+			streaks.CurrentLoss++
+			streaks.CurrentWin = 0
+		}
+
+		if streaks.CurrentWin > streaks.LongestWin {
+			streaks.LongestWin = streaks.CurrentWin
+		}
+		if streaks.CurrentLoss > streaks.LongestLoss {
+			streaks.LongestLoss = streaks.CurrentLoss
+		}
+	}
+
+	return streaks
+}
@@ -0,0 +1,30 @@
+package store
+
+import "testing"
+
+func TestComputeStreaks(t *testing.T) {
+	win := Match{TeamA: []string{"p"}, TeamB: []string{"o"}, ScoreA: 21, ScoreB: 15}
+	loss := Match{TeamA: []string{"p"}, TeamB: []string{"o"}, ScoreA: 15, ScoreB: 21}
+	draw := Match{TeamA: []string{"p"}, TeamB: []string{"o"}, ScoreA: 80, ScoreB: 80}
+
+	tests := []struct {
+		name    string
+		matches []Match
+		streaks Streaks
+	}{
+		{"no matches", nil, Streaks{}},
+		{"current win streak", []Match{win, win, loss}, Streaks{CurrentWin: 2, LongestWin: 2, LongestLoss: 1}},
+		{"current loss streak", []Match{loss, win, win, win}, Streaks{CurrentLoss: 1, LongestWin: 3, LongestLoss: 1}},
+		{"draw breaks the win streak", []Match{win, draw, win, win}, Streaks{CurrentWin: 1, LongestWin: 2}},
+		{"draw breaks the loss streak", []Match{draw, loss, loss}, Streaks{LongestLoss: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// matches are given latest first
+			if streaks := computeStreaks(tt.matches, "p"); streaks != tt.streaks {
+				t.Errorf("computeStreaks() = %+v, want %+v", streaks, tt.streaks)
+			}
+		})
+	}
+}
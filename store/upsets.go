@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GiantKiller is the record of a player in the matches played as underdog or as favourite
+type GiantKiller struct {
+	Player          string  `json:"player"`
+	UnderdogMatches int     `json:"underdog_matches"`
+	UpsetWins       int     `json:"upset_wins"`
+	UpsetWinRate    float64 `json:"upset_win_rate"`
+	FavouriteLosses int     `json:"favourite_losses"`
+	BiggestUpset    *Match  `json:"biggest_upset,omitempty"`
+}
+
+// get the upsets of a sport, from the most to the least surprising one
+func (s *MongoSportStore) GetUpsets(ctx context.Context, limit int, sport Sport) ([]Match, error) {
+	dbName := s.sportDBs[sport]
+	collection := s.client.Database(dbName).Collection(s.matchCollection)
+
+	filter := bson.M{"upset": true}
+
+	orderSurprise := bson.D{{"surprise", -1}, {"date", -1}}
+	sorting := options.Find().SetSort(orderSurprise).SetLimit(int64(limit))
+
+	results, err := collection.Find(ctx, filter, sorting)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve matches: %w", err)
+	}
+
+	matches := []Match{}
+
+	for results.Next(ctx) {
+		match := Match{}
+		if err := results.Decode(&match); err != nil {
+			return nil, fmt.Errorf("failed to retrieve matches: %w", err)
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, nil
+}
+
+func (s *MongoSportStore) GetGiantKiller(ctx context.Context, playerName string, sport Sport) (*GiantKiller, error) {
+	if _, err := s.findPlayer(ctx, playerName, sport); err != nil {
+		return nil, err
+	}
+
+	// get all matches for given player
+	filter := bson.M{"$or": []bson.M{{"team_a": playerName}, {"team_b": playerName}}}
+
+	matches, err := s.findMatches(ctx, filter, sport)
+	if err != nil {
+		return nil, err
+	}
+
+	return computeGiantKiller(matches, playerName), nil
+}
+
+// --------------------- FUNCTIONS
+
+// surprise is the probability, before the match, that the winning team would lose it;
+// the match is an upset when the winning team was the underdog. Draws have no winning team
+func computeSurprise(m *Match, teamAExpected float64) (float64, bool) {
+	if m.IsDraw() {
+		return 0, false
+	}
+
+	winnerExpected := 1 - teamAExpected
+	if m.ScoreA > m.ScoreB {
+		winnerExpected = teamAExpected
+	}
+
+	return 1 - winnerExpected, winnerExpected < 0.5
+}
+
+// compute the underdog/favourite record of a player; matches recorded before surprise was tracked are skipped
+func computeGiantKiller(matches []Match, playerName string) *GiantKiller {
+	gk := &GiantKiller{Player: playerName}
+
+	for i, m := range matches {
+		// the winning team can't be sure to win, so surprise is zero only if it's missing or for draws
+		if m.Surprise == 0 {
+			continue
+		}
+
+		_, _, _, _, isWinner := matchSide(m, playerName)
+
+		expected := m.Surprise
+		if isWinner {
+			expected = 1 - m.Surprise
+		}
+
+		if expected < 0.5 {
+			gk.UnderdogMatches++
+			if isWinner {
+				gk.UpsetWins++
+				if gk.BiggestUpset == nil || m.Surprise > gk.BiggestUpset.Surprise {
+					gk.BiggestUpset = &matches[i]
+				}
+			}
+		} else if expected > 0.5 && !isWinner {
+			gk.FavouriteLosses++
+		}
+	}
+
+	if gk.UnderdogMatches > 0 {
+		gk.UpsetWinRate = float64(gk.UpsetWins) / float64(gk.UnderdogMatches)
+	}
+
+	return gk
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// MatchRules are the constraints a match of a sport must satisfy to be recorded
+type MatchRules struct {
+	MinTeamSize int
+	MaxTeamSize int
+	// points needed to win, 0 if matches are not played to a target score
+	TargetPoints int
+	// minimum winning margin; past the target points the match ends as soon as the margin is reached
+	WinBy int
+	// sets needed to win a match played in sets, 0 if the sport is not played in sets
+	SetsToWin int
+	// points needed to win the deciding set
+	TiebreakPoints int
+	// whether a match can end tied
+	AllowDraws bool
+	// individual stats that can be recorded for the players of a match
+	Stats []StatDefinition
+}
+
+var SportMatchRules = map[Sport]MatchRules{
+	Beachvolley: {MinTeamSize: 1, MaxTeamSize: 4, TargetPoints: 21, WinBy: 2, SetsToWin: 2, TiebreakPoints: 15},
+	Basket:      {MinTeamSize: 1, MaxTeamSize: 5, WinBy: 1, AllowDraws: true, Stats: basketStats},
+	Pool:        {MinTeamSize: 1, MaxTeamSize: 1, WinBy: 1, Stats: poolStats},
+}
+
+// matches can't be dated in the future, except for a small clock difference with the client
+const maxClockSkew = 5 * time.Minute
+
+const defaultMatchMaxAge = 30 * 24 * time.Hour
+
+// matches already checked against the date window, e.g. imported or pending ones, can be as old as needed,
+// but not in the future
+const noMatchMaxAge = time.Duration(math.MaxInt64)
+
+const (
+	maxNotesLength    = 500
+	maxLocationLength = 50
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every field of a match violating the sport rules
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", f.Field, f.Message))
+	}
+	return fmt.Sprintf("%s: %s", ErrNotValidMatch, strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrNotValidMatch
+}
+
+// check the match, dated within maxAge, against the rules of the sport and that every registered player exists;
+// guests are registered on their first match, so they are not required to exist
+func (s *MongoSportStore) validateMatch(ctx context.Context, m *Match, maxAge time.Duration, sport Sport) error {
+	fields := checkMatch(m, SportMatchRules[sport], time.Now(), maxAge)
+
+	for _, team := range []struct {
+		field   string
+		players []string
+	}{{"team_a", m.TeamA}, {"team_b", m.TeamB}} {
+		for _, name := range team.players {
+			if IsGuest(name) || strings.TrimSpace(name) == "" {
+				continue
+			}
+			_, err := s.findPlayer(ctx, name, sport)
+			if errors.Is(err, ErrNoPlayerFound) {
+				fields = append(fields, FieldError{Field: team.field, Message: fmt.Sprintf("player %s does not exist", name)})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func matchMaxAgeFromConfig() time.Duration {
+	if viper.IsSet("MATCH_MAX_AGE") && viper.GetDuration("MATCH_MAX_AGE") > 0 {
+		return viper.GetDuration("MATCH_MAX_AGE")
+	}
+	return defaultMatchMaxAge
+}
+
+// --------------------- FUNCTIONS
+
+// return the fields of the match violating the rules: team sizes, rosters, scores, stat lines, details and date window
+func checkMatch(m *Match, rules MatchRules, now time.Time, maxAge time.Duration) []FieldError {
+	var fields []FieldError
+
+	// rosters
+	seen := make(map[string]string)
+	for _, team := range []struct {
+		field   string
+		players []string
+	}{{"team_a", m.TeamA}, {"team_b", m.TeamB}} {
+		if len(team.players) < rules.MinTeamSize || len(team.players) > rules.MaxTeamSize {
+			fields = append(fields, FieldError{
+				Field:   team.field,
+				Message: fmt.Sprintf("team must have between %d and %d players", rules.MinTeamSize, rules.MaxTeamSize),
+			})
+		}
+
+		for _, name := range team.players {
+			if strings.TrimSpace(name) == "" || name == GuestPrefix {
+				fields = append(fields, FieldError{Field: team.field, Message: "player name is empty"})
+				continue
+			}
+			if other, ok := seen[name]; ok {
+				message := fmt.Sprintf("player %s is listed twice", name)
+				if other != team.field {
+					message = fmt.Sprintf("player %s also plays in %s", name, other)
+				}
+				fields = append(fields, FieldError{Field: team.field, Message: message})
+				continue
+			}
+			seen[name] = team.field
+		}
+	}
+
+	// scores, set by set if the match was played in sets
+	if len(m.Sets) > 0 {
+		fields = append(fields, checkSets(m.Sets, rules)...)
+	} else {
+		if m.ScoreA < 0 {
+			fields = append(fields, FieldError{Field: "score_a", Message: "score can't be negative"})
+		}
+		if m.ScoreB < 0 {
+			fields = append(fields, FieldError{Field: "score_b", Message: "score can't be negative"})
+		}
+		if m.ScoreA >= 0 && m.ScoreB >= 0 {
+			if m.IsDraw() && !rules.AllowDraws {
+				fields = append(fields, FieldError{Field: "score", Message: "draws are not allowed"})
+			} else if !m.IsDraw() {
+				fields = append(fields, checkScore(m.ScoreA, m.ScoreB, rules.TargetPoints, rules.WinBy, "score")...)
+			}
+		}
+	}
+
+	fields = append(fields, checkStatLines(m, rules.Stats)...)
+
+	// details
+	if len(m.Location) > maxLocationLength {
+		fields = append(fields, FieldError{Field: "location", Message: fmt.Sprintf("location can't be longer than %d characters", maxLocationLength)})
+	}
+	if len(m.Notes) > maxNotesLength {
+		fields = append(fields, FieldError{Field: "notes", Message: fmt.Sprintf("notes can't be longer than %d characters", maxNotesLength)})
+	}
+	if m.DurationMinutes < 0 {
+		fields = append(fields, FieldError{Field: "duration_minutes", Message: "duration can't be negative"})
+	}
+
+	// date window
+	if m.Date.IsZero() {
+		fields = append(fields, FieldError{Field: "date", Message: "date is required"})
+	} else if m.Date.After(now.Add(maxClockSkew)) {
+		fields = append(fields, FieldError{Field: "date", Message: "date can't be in the future"})
+	} else if m.Date.Before(now.Add(-maxAge)) {
+		fields = append(fields, FieldError{Field: "date", Message: fmt.Sprintf("date can't be older than %s", maxAge)})
+	}
+
+	return fields
+}
+
+// check a final score against target points and winning margin
+func checkScore(scoreA int, scoreB int, targetPoints int, winBy int, field string) []FieldError {
+	winner, loser := scoreA, scoreB
+	if scoreB > scoreA {
+		winner, loser = scoreB, scoreA
+	}
+
+	if winner-loser < winBy {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("winning margin must be at least %d", winBy)}}
+	}
+	if targetPoints == 0 {
+		return nil
+	}
+	if winner < targetPoints {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("winning team must score at least %d points", targetPoints)}}
+	}
+	if winner > targetPoints && winner-loser != winBy {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("past %d points the match ends with a margin of %d", targetPoints, winBy)}}
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckMatch(t *testing.T) {
+	now := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		sport  Sport
+		match  Match
+		fields []string
+	}{
+		{
+			name:  "valid match",
+			sport: Beachvolley,
+			match: Match{TeamA: []string{"a", "b"}, TeamB: []string{"c", "d"}, ScoreA: 21, ScoreB: 18, Date: now},
+		},
+		{
+			name:   "one point margin",
+			sport:  Beachvolley,
+			match:  Match{TeamA: []string{"a", "b"}, TeamB: []string{"c", "d"}, ScoreA: 21, ScoreB: 20, Date: now},
+			fields: []string{"score"},
+		},
+		{
+			name:  "sets instead of score",
+			sport: Beachvolley,
+			match: Match{TeamA: []string{"a"}, TeamB: []string{"b"}, Sets: []SetScore{{21, 15}, {18, 21}, {15, 13}}, Date: now},
+		},
+		{
+			name:  "draw in a sport allowing draws",
+			sport: Basket,
+			match: Match{TeamA: []string{"a"}, TeamB: []string{"b"}, ScoreA: 80, ScoreB: 80, Date: now},
+		},
+		{
+			name:   "draw in a sport not allowing draws",
+			sport:  Pool,
+			match:  Match{TeamA: []string{"a"}, TeamB: []string{"b"}, ScoreA: 3, ScoreB: 3, Date: now},
+			fields: []string{"score"},
+		},
+		{
+			name:   "sets tied",
+			sport:  Beachvolley,
+			match:  Match{TeamA: []string{"a"}, TeamB: []string{"b"}, Sets: []SetScore{{21, 15}, {15, 21}}, Date: now},
+			fields: []string{"sets"},
+		},
+		{
+			name:   "team too large",
+			sport:  Beachvolley,
+			match:  Match{TeamA: []string{"a", "b", "c", "d", "e"}, TeamB: []string{"f"}, ScoreA: 21, ScoreB: 18, Date: now},
+			fields: []string{"team_a"},
+		},
+		{
+			name:   "empty team",
+			sport:  Pool,
+			match:  Match{TeamA: []string{}, TeamB: []string{"b"}, ScoreA: 1, ScoreB: 0, Date: now},
+			fields: []string{"team_a"},
+		},
+		{
+			name:   "player in both teams",
+			sport:  Beachvolley,
+			match:  Match{TeamA: []string{"a", "b"}, TeamB: []string{"b", "c"}, ScoreA: 21, ScoreB: 18, Date: now},
+			fields: []string{"team_b"},
+		},
+		{
+			name:   "empty guest name",
+			sport:  Beachvolley,
+			match:  Match{TeamA: []string{GuestPrefix}, TeamB: []string{"b"}, ScoreA: 21, ScoreB: 18, Date: now},
+			fields: []string{"team_a"},
+		},
+		{
+			name:   "negative score",
+			sport:  Pool,
+			match:  Match{TeamA: []string{"a"}, TeamB: []string{"b"}, ScoreA: -1, ScoreB: 3, Date: now},
+			fields: []string{"score_a"},
+		},
+		{
+			name:   "sets in a sport without sets",
+			sport:  Pool,
+			match:  Match{TeamA: []string{"a"}, TeamB: []string{"b"}, Sets: []SetScore{{3, 1}}, Date: now},
+			fields: []string{"sets"},
+		},
+		{
+			name:   "notes too long",
+			sport:  Pool,
+			match:  Match{TeamA: []string{"a"}, TeamB: []string{"b"}, ScoreA: 3, ScoreB: 1, Date: now, Notes: strings.Repeat("x", maxNotesLength+1)},
+			fields: []string{"notes"},
+		},
+		{
+			name:   "negative duration",
+			sport:  Pool,
+			match:  Match{TeamA: []string{"a"}, TeamB: []string{"b"}, ScoreA: 3, ScoreB: 1, Date: now, DurationMinutes: -5},
+			fields: []string{"duration_minutes"},
+		},
+		{
+			name:   "missing date",
+			sport:  Pool,
+			match:  Match{TeamA: []string{"a"}, TeamB: []string{"b"}, ScoreA: 3, ScoreB: 1},
+			fields: []string{"date"},
+		},
+		{
+			name:  "date within the clock skew",
+			sport: Pool,
+			match: Match{TeamA: []string{"a"}, TeamB: []string{"b"}, ScoreA: 3, ScoreB: 1, Date: now.Add(maxClockSkew)},
+		},
+		{
+			name:   "date in the future",
+			sport:  Pool,
+			match:  Match{TeamA: []string{"a"}, TeamB: []string{"b"}, ScoreA: 3, ScoreB: 1, Date: now.Add(time.Hour)},
+			fields: []string{"date"},
+		},
+		{
+			name:   "date too old",
+			sport:  Pool,
+			match:  Match{TeamA: []string{"a"}, TeamB: []string{"b"}, ScoreA: 3, ScoreB: 1, Date: now.Add(-defaultMatchMaxAge - time.Hour)},
+			fields: []string{"date"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFields(t, checkMatch(&tt.match, SportMatchRules[tt.sport], now, defaultMatchMaxAge), tt.fields)
+		})
+	}
+}
+
+func TestCheckMatchWithoutDateWindow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	m := Match{TeamA: []string{"a"}, TeamB: []string{"b"}, ScoreA: 3, ScoreB: 1, Date: now.AddDate(-5, 0, 0)}
+
+	assertFields(t, checkMatch(&m, SportMatchRules[Pool], now, noMatchMaxAge), nil)
+}
+
+func TestCheckScore(t *testing.T) {
+	tests := []struct {
+		name           string
+		scoreA, scoreB int
+		target, winBy  int
+		valid          bool
+	}{
+		{"no target", 7, 3, 0, 1, true},
+		{"no target, even", 3, 3, 0, 1, false},
+		{"target reached", 21, 19, 21, 2, true},
+		{"target reached by team b", 15, 21, 21, 2, true},
+		{"margin too small", 21, 20, 21, 2, false},
+		{"target not reached", 19, 17, 21, 2, false},
+		{"deuce", 22, 20, 21, 2, true},
+		{"deuce not over", 23, 20, 21, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := checkScore(tt.scoreA, tt.scoreB, tt.target, tt.winBy, "score")
+			if valid := len(fields) == 0; valid != tt.valid {
+				t.Errorf("checkScore(%d, %d) = %v, want valid %t", tt.scoreA, tt.scoreB, fields, tt.valid)
+			}
+		})
+	}
+}
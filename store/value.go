@@ -0,0 +1,90 @@
+package store
+
+import (
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultLatestPeriod = 3
+	defaultFormWeight   = 1.0
+)
+
+// ValueModel holds the parameters used to compute the RealTimeValue (rtValue) of a player
+type ValueModel struct {
+	// number of latest matches considered to evaluate the current form of a player
+	LatestPeriod int
+	// multiplier applied to the form bonus/malus; 0 disables the form contribution
+	FormWeight float64
+}
+
+// PlayerValue is the RealTimeValue of a player together with the components it is made of
+type PlayerValue struct {
+	Name             string  `json:"name"`
+	Value            float64 `json:"value"`
+	Base             float64 `json:"base"`
+	FormBonus        float64 `json:"form_bonus"`
+	ConfidenceWeight float64 `json:"confidence_weight"`
+	HistoricAvg      float64 `json:"historic_avg"`
+	RecentAvg        float64 `json:"recent_avg"`
+	LatestPeriod     int     `json:"latest_period"`
+	FormWeight       float64 `json:"form_weight"`
+}
+
+// read the value model parameters from configuration (RTV_LATEST_PERIOD, RTV_FORM_WEIGHT), falling back to defaults
+func newValueModelFromConfig() ValueModel {
+	vm := ValueModel{
+		LatestPeriod: defaultLatestPeriod,
+		FormWeight:   defaultFormWeight,
+	}
+
+	if viper.IsSet("RTV_LATEST_PERIOD") && viper.GetInt("RTV_LATEST_PERIOD") > 0 {
+		vm.LatestPeriod = viper.GetInt("RTV_LATEST_PERIOD")
+	}
+	if viper.IsSet("RTV_FORM_WEIGHT") && viper.GetFloat64("RTV_FORM_WEIGHT") >= 0 {
+		vm.FormWeight = viper.GetFloat64("RTV_FORM_WEIGHT")
+	}
+
+	return vm
+}
+
+// compute RealTimeValue for a player as
+//
+//	value = base + formBonus , where
+//	base is the last elo of the player
+//	formBonus = (recentAvg - historicAvg) * confidence * formWeight
+//		recentAvg is the elo average over the latest period, starting from the second-latest match
+//		historicAvg is the elo average over the whole history
+//	confidence = (matchCount - latestPeriod) / matchCount in [0,1)
+//		the higher the matchCount wrt the latest period length, the higher the confidence in the bonus/malus
+//
+// players with not enough history (elo entries or matches) to fill the latest period get no bonus/malus
+func (vm ValueModel) computeRealTimePlayerValue(p *Player) *PlayerValue {
+	latestPeriod := vm.LatestPeriod
+	if latestPeriod <= 0 {
+		latestPeriod = defaultLatestPeriod
+	}
+
+	pv := &PlayerValue{
+		Name:         p.Name,
+		Base:         p.LastElo,
+		LatestPeriod: latestPeriod,
+		FormWeight:   vm.FormWeight,
+	}
+
+	if len(p.Elo) > latestPeriod && p.MatchCount > latestPeriod {
+		// starting from second-latest match fill the sub-elo trend
+		e := make([]float64, latestPeriod)
+		for i := 1; i <= latestPeriod; i++ {
+			e[i-1] = p.Elo[len(p.Elo)-1-i]
+		}
+
+		pv.HistoricAvg = computeAvg(p.Elo)
+		pv.RecentAvg = computeAvg(e)
+		pv.ConfidenceWeight = float64(p.MatchCount-latestPeriod) / float64(p.MatchCount)
+		pv.FormBonus = (pv.RecentAvg - pv.HistoricAvg) * pv.ConfidenceWeight * vm.FormWeight
+	}
+
+	pv.Value = pv.Base + pv.FormBonus
+
+	return pv
+}
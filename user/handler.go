@@ -39,6 +39,15 @@ func RegisterUser(ctx *gin.Context) {
 		return
 	}
 
+	// the guest prefix tells guests apart from registered players
+	if store.IsGuest(user.Name) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"message": "user name is not valid",
+		})
+
+		return
+	}
+
 	if err := HashPassword(user); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"message": "failed to encrypt password",